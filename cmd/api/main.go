@@ -15,6 +15,11 @@
 //
 // @host localhost:8000
 // @BasePath /api/v1
+//
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Type "Bearer" followed by a space and the access token.
 package main
 
 import (
@@ -24,33 +29,82 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Organization time zones are validated without relying on the host's zoneinfo
 
+	"github.com/off-by-2/sal/internal/auth"
 	"github.com/off-by-2/sal/internal/config"
 	"github.com/off-by-2/sal/internal/database"
+	"github.com/off-by-2/sal/internal/mail"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/scheduler"
 )
 
 func main() {
-	// 1. Load Configuration
-	cfg := config.Load()
+	// 1. Load Configuration (refuses to start on invalid values)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	log.Printf("Configuration:\n%s", cfg.Summary())
 
 	// 2. Connect to Database
-	db, err := database.New(context.Background(), cfg.DatabaseURL)
+	db, err := database.New(context.Background(), cfg.DatabaseURL, database.PoolOptions{
+		MaxConns:        cfg.DBMaxConns,
+		MinConns:        cfg.DBMinConns,
+		MaxConnLifetime: cfg.DBMaxConnLifetime,
+		MaxConnIdleTime: cfg.DBMaxConnIdleTime,
+	})
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer db.Close()
 
-	// 3. Initialize Server
-	server := NewServer(cfg, db)
+	// 3. Initialize Mailer
+	mailer, err := mail.New(mail.Options{
+		Driver:       cfg.MailDriver,
+		From:         cfg.MailFrom,
+		Dir:          cfg.MailDir,
+		SMTPAddr:     cfg.SMTPAddr,
+		SMTPUsername: cfg.SMTPUsername,
+		SMTPPassword: cfg.SMTPPassword,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+
+	// 4. Load Token Signing Keys
+	// Without a key directory, tokens are signed with JWT_SECRET (HS256), which is fine for development only.
+	keys := auth.NewHMACKeyRing(cfg.JWTSecret)
+	if cfg.JWTKeyDir != "" {
+		keys, err = auth.LoadKeyRing(cfg.JWTKeyDir, cfg.JWTActiveKeyID)
+		if err != nil {
+			log.Fatalf("Failed to load signing keys: %v", err)
+		}
+	}
+
+	// 5. Schedule Background Jobs
+	// Every replica schedules them; the one holding the leader lock runs them.
+	jobs := scheduler.New(scheduler.NewPostgresElector(db, scheduler.LeaderLock), scheduler.DefaultElectionInterval)
+	invitations := repository.NewInvitationRepository(db)
+	jobs.Every("expire-invitations", 15*time.Minute, func(ctx context.Context) error {
+		n, err := invitations.ExpireInvitations(ctx)
+		if n > 0 {
+			log.Printf("Expired %d invitations", n)
+		}
+		return err
+	})
+
+	// 6. Initialize Server
+	server := NewServer(cfg, db, mailer, keys, jobs)
 
-	// 4. Start Server (in a goroutine so we can listen for shutdown signals)
+	// 7. Start Server and Background Jobs (in a goroutine so we can listen for shutdown signals)
 	go func() {
 		if err := server.Start(); err != nil {
 			log.Printf("Server stopped: %v", err)
 		}
 	}()
 
-	// 5. Graceful Shutdown
+	// 8. Graceful Shutdown
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -4,6 +4,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -14,11 +15,15 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	_ "github.com/off-by-2/sal/docs" // Swagger docs
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/authz"
 	"github.com/off-by-2/sal/internal/config"
 	"github.com/off-by-2/sal/internal/database"
 	"github.com/off-by-2/sal/internal/handler"
+	"github.com/off-by-2/sal/internal/mail"
 	"github.com/off-by-2/sal/internal/repository"
 	"github.com/off-by-2/sal/internal/response"
+	"github.com/off-by-2/sal/internal/scheduler"
 )
 
 // Server is the main HTTP server container.
@@ -27,15 +32,25 @@ type Server struct {
 	Router *chi.Mux           // Router handles HTTP routing
 	DB     *database.Postgres // DB provides access to the database connection pool
 	Config *config.Config     // Config holds application configuration
+	Mailer mail.Mailer        // Mailer delivers transactional emails
+	Keys   *auth.KeyRing      // Keys sign and verify access tokens
 	server *http.Server       // server is the underlying HTTP server instance
+
+	activity *auth.ActivityTracker // activity batches users.last_activity_at writes
+	auth     *handler.AuthHandler  // auth sends emails after responding, such as password resets
+	jobs     *scheduler.Scheduler  // jobs runs periodic background jobs
 }
 
 // NewServer creates and configures a new HTTP server.
-func NewServer(cfg *config.Config, db *database.Postgres) *Server {
+// jobs is started and stopped with the server.
+func NewServer(cfg *config.Config, db *database.Postgres, mailer mail.Mailer, keys *auth.KeyRing, jobs *scheduler.Scheduler) *Server {
 	s := &Server{
 		Router: chi.NewRouter(),
 		DB:     db,
 		Config: cfg,
+		Mailer: mailer,
+		Keys:   keys,
+		jobs:   jobs,
 	}
 
 	s.routes() // Set up routes
@@ -46,20 +61,30 @@ func NewServer(cfg *config.Config, db *database.Postgres) *Server {
 // Start runs the HTTP server.
 func (s *Server) Start() error {
 	s.server = &http.Server{
-		Addr:         fmt.Sprintf(":%s", s.Config.Port),
+		Addr:         fmt.Sprintf(":%d", s.Config.Port),
 		Handler:      s.Router,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  time.Minute,
 	}
 
-	fmt.Printf("Server starting on port %s\n", s.Config.Port)
+	s.activity.Start()
+	s.jobs.Start()
+
+	fmt.Printf("Server starting on port %d\n", s.Config.Port)
 	return s.server.ListenAndServe()
 }
 
-// Shutdown gracefully stops the HTTP server.
+// Shutdown gracefully stops the HTTP server, waits for the emails it still sends and stops the background jobs,
+// then writes the remaining user activity.
+// Every step runs even if an earlier one fails, so leadership is released and activity is not lost.
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.server.Shutdown(ctx)
+	return errors.Join(
+		s.server.Shutdown(ctx),
+		s.auth.Wait(ctx),
+		s.jobs.Stop(ctx),
+		s.activity.Stop(ctx),
+	)
 }
 
 // routes configures the API routes.
@@ -70,8 +95,8 @@ func (s *Server) routes() {
 	s.Router.Use(middleware.Logger)
 	s.Router.Use(middleware.Recoverer)
 	s.Router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"}, // TODO: Restrict in production
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedOrigins:   s.Config.CORSAllowedOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
@@ -85,9 +110,28 @@ func (s *Server) routes() {
 	userRepo := repository.NewUserRepository(s.DB)
 	orgRepo := repository.NewOrganizationRepository(s.DB)
 	staffRepo := repository.NewStaffRepository(s.DB)
+	sessionRepo := repository.NewSessionRepository(s.DB)
+	activityRepo := repository.NewActivityLogRepository(s.DB)
+	apiKeyRepo := repository.NewAPIKeyRepository(s.DB)
+	invitationRepo := repository.NewInvitationRepository(s.DB)
+	beneficiaryRepo := repository.NewBeneficiaryRepository(s.DB)
+
+	// Authorization
+	authorizer := authz.NewAuthorizer(staffRepo, authz.DefaultCacheTTL, s.Config.ReauthMaxAge)
+	s.activity = auth.NewActivityTracker(userRepo, auth.DefaultActivityInterval)
 
 	// Handlers
-	authHandler := handler.NewAuthHandler(s.DB, userRepo, orgRepo, staffRepo, s.Config.JWTSecret)
+	authHandler := handler.NewAuthHandler(s.DB, userRepo, orgRepo, staffRepo, sessionRepo, s.Mailer, s.Keys, s.Config)
+	s.auth = authHandler
+	staffHandler := handler.NewStaffHandler(staffRepo, userRepo, sessionRepo)
+	impersonationHandler := handler.NewImpersonationHandler(userRepo, staffRepo, sessionRepo, activityRepo, s.Keys, s.Config)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyRepo, activityRepo)
+	invitationHandler := handler.NewInvitationHandler(invitationRepo, s.Mailer, authHandler.Hasher, authHandler.Passwords, s.Config)
+	orgHandler := handler.NewOrgHandler(orgRepo, userRepo, activityRepo)
+	beneficiaryHandler := handler.NewBeneficiaryHandler(beneficiaryRepo)
+
+	// Token verification keys for other services
+	s.Router.Get("/.well-known/jwks.json", authHandler.JWKS)
 
 	// API Group
 	s.Router.Route("/api/v1", func(r chi.Router) {
@@ -96,7 +140,33 @@ func (s *Server) routes() {
 		})
 
 		// Auth Config
-		r.Mount("/auth", authRouter(authHandler))
+		r.Mount("/auth", authRouter(authHandler, impersonationHandler))
+
+		// Invitees have no token yet: the emailed one is their credential
+		r.Post("/invitations/accept", invitationHandler.Accept)
+
+		// Authenticated routes: everything below requires a valid bearer token or API key
+		r.Group(func(r chi.Router) {
+			r.Use(auth.Authenticate(authHandler.Verifier.WithAPIKeys(apiKeyHandler)))
+			r.Use(auth.TrackActivity(s.activity))
+			r.Use(impersonationHandler.AuditWrites)
+			r.Use(apiKeyHandler.AuditWrites)
+
+			r.With(auth.DenyImpersonation, auth.DenyAPIKeys).Post("/impersonation", impersonationHandler.Start)
+			r.Post("/impersonation/stop", impersonationHandler.Stop)
+			r.Mount("/admin", adminRouter(orgHandler))
+
+			// Organization data: read-only while the organization is suspended in read-only mode
+			r.Group(func(r chi.Router) {
+				r.Use(auth.DenySuspendedWrites)
+
+				r.Mount("/staff", staffRouter(staffHandler, authorizer))
+				r.Mount("/api-keys", apiKeyRouter(apiKeyHandler, authorizer))
+				r.Mount("/invitations", invitationRouter(invitationHandler, authorizer))
+				r.Mount("/orgs", orgRouter(orgHandler, authorizer))
+				r.Mount("/beneficiaries", beneficiaryRouter(beneficiaryHandler, authorizer))
+			})
+		})
 	})
 
 	// Swagger UI
@@ -105,10 +175,83 @@ func (s *Server) routes() {
 	))
 }
 
-func authRouter(h *handler.AuthHandler) http.Handler {
+func authRouter(h *handler.AuthHandler, ih *handler.ImpersonationHandler) http.Handler {
 	r := chi.NewRouter()
+	authed := r.With(auth.Authenticate(h.Verifier), ih.AuditWrites)
+
 	r.Post("/register", h.Register)
 	r.Post("/login", h.Login)
+	r.Get("/csrf", h.CSRFToken)
+	r.With(h.RequireCSRF).Post("/refresh", h.Refresh)
+	r.With(h.RequireCSRF).Post("/logout", h.Logout)
+	r.With(h.RequireCSRF).Post("/logout-all", h.LogoutAll)
+	authed.With(auth.DenyImpersonation).Post("/switch-org", h.SwitchOrg)
+	authed.With(auth.DenyImpersonation).Post("/reauthenticate", h.Reauthenticate)
+	r.Post("/verify-email", h.VerifyEmail)
+	authed.Post("/verify-email/resend", h.ResendVerification)
+	r.Post("/password/forgot", h.ForgotPassword)
+	r.Post("/password/reset", h.ResetPassword)
+	authed.With(auth.DenyImpersonation).Post("/password/change", h.ChangePassword)
+	authed.Get("/sessions", h.ListSessions)
+	authed.With(auth.DenyImpersonation).Delete("/sessions/{id}", h.RevokeSession)
+	r.Post("/2fa/verify", h.VerifyTwoFactor)
+	enrolling := r.With(h.AuthenticateEnrollment(chi.Chain(auth.Authenticate(h.Verifier), ih.AuditWrites, auth.DenyImpersonation).Handler))
+	enrolling.Post("/2fa/enroll", h.EnrollTwoFactor)
+	enrolling.Post("/2fa/confirm", h.ConfirmTwoFactor)
+	r.Post("/oidc/{provider}/start", h.StartOIDC)
+	r.Post("/oidc/{provider}/callback", h.OIDCCallback)
+	return r
+}
+
+func staffRouter(h *handler.StaffHandler, az *authz.Authorizer) http.Handler {
+	r := chi.NewRouter()
+	r.With(az.RequirePermission(repository.CapStaffManage)).Get("/inactive", h.Inactive)
+	r.With(auth.DenyImpersonation, az.RequirePermission(repository.CapStaffManage)).Post("/{id}/deactivate", h.Deactivate)
+	r.With(auth.DenyImpersonation, az.RequirePermission(repository.CapStaffManage)).Post("/{id}/unlock", h.Unlock)
+	r.With(az.RequirePermission(repository.CapStaffManage)).Get("/{id}/sessions", h.ListSessions)
+	r.With(auth.DenyImpersonation, az.RequirePermission(repository.CapStaffManage)).Delete("/{id}/sessions/{sessionID}", h.RevokeSession)
+	return r
+}
+
+func apiKeyRouter(h *handler.APIKeyHandler, az *authz.Authorizer) http.Handler {
+	r := chi.NewRouter()
+	r.With(az.RequirePermission(repository.CapStaffManage)).Get("/", h.List)
+	r.With(auth.DenyImpersonation, az.RequirePermission(repository.CapStaffManage)).Post("/", h.Create)
+	r.With(auth.DenyImpersonation, az.RequirePermission(repository.CapStaffManage)).Delete("/{id}", h.Revoke)
+	return r
+}
+
+func invitationRouter(h *handler.InvitationHandler, az *authz.Authorizer) http.Handler {
+	r := chi.NewRouter()
+	r.Use(az.RequirePermission(repository.CapStaffInvite))
+	r.Get("/", h.List)
+	r.With(auth.DenyImpersonation, auth.DenyAPIKeys).Post("/", h.Create)
+	r.With(auth.DenyImpersonation).Post("/{id}/resend", h.Resend)
+	r.With(auth.DenyImpersonation).Delete("/{id}", h.Revoke)
+	return r
+}
+
+// beneficiaryRouter serves beneficiaries. They record the person who created them, so API keys are refused.
+func beneficiaryRouter(h *handler.BeneficiaryHandler, az *authz.Authorizer) http.Handler {
+	r := chi.NewRouter()
+	r.With(auth.DenyAPIKeys, az.RequirePermission(repository.CapBeneficiariesCreate)).Post("/", h.Create)
+	return r
+}
+
+func orgRouter(h *handler.OrgHandler, az *authz.Authorizer) http.Handler {
+	r := chi.NewRouter()
+	r.With(az.RequireMember()).Get("/{id}", h.Get)
+	r.With(az.RequireMember()).Get("/{id}/usage", h.Usage)
+	r.With(az.RequireRole(authz.RoleAdmin)).Patch("/{id}", h.Update)
+	return r
+}
+
+// adminRouter serves the platform admins' routes, checked by the handlers.
+func adminRouter(h *handler.OrgHandler) http.Handler {
+	r := chi.NewRouter()
+	r.Use(auth.DenyImpersonation, auth.DenyAPIKeys)
+	r.Post("/orgs/{id}/suspend", h.Suspend)
+	r.Post("/orgs/{id}/reinstate", h.Reinstate)
 	return r
 }
 
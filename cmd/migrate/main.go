@@ -21,7 +21,10 @@ func main() {
 	flag.Parse()
 
 	// Load config to get DB URL
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
 
 	db, err := goose.OpenDBWithDriver("pgx", cfg.DatabaseURL)
 	if err != nil {
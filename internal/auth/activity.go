@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultActivityInterval is how often an ActivityTracker writes, and so how stale last_activity_at may be.
+const DefaultActivityInterval = time.Minute
+
+// maxPendingActivity triggers an early flush, bounding the memory an ActivityTracker holds.
+const maxPendingActivity = 5000
+
+// ActivityRecorder persists when users were last active.
+type ActivityRecorder interface {
+	TouchUsers(ctx context.Context, seen map[string]time.Time) error
+}
+
+// ActivityTracker records when users were last active without an UPDATE per request:
+// requests only mark the user in memory, and marked users are written in one batch every interval.
+// A user is therefore written at most once per interval however many requests they make.
+type ActivityTracker struct {
+	store    ActivityRecorder
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[string]time.Time
+
+	flush chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewActivityTracker creates an ActivityTracker writing to store every interval.
+// Call Start to begin writing and Stop to write the last batch.
+func NewActivityTracker(store ActivityRecorder, interval time.Duration) *ActivityTracker {
+	return &ActivityTracker{
+		store:    store,
+		interval: interval,
+		pending:  make(map[string]time.Time),
+		flush:    make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Touch marks userID as active now.
+func (t *ActivityTracker) Touch(userID string) {
+	t.mu.Lock()
+	t.pending[userID] = time.Now()
+	full := len(t.pending) >= maxPendingActivity
+	t.mu.Unlock()
+
+	if full {
+		select {
+		case t.flush <- struct{}{}:
+		default: // A flush is already requested
+		}
+	}
+}
+
+// Flush writes the pending batch. Failed batches are merged back and retried with the next one.
+func (t *ActivityTracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	batch := t.pending
+	t.pending = make(map[string]time.Time, len(batch))
+	t.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if err := t.store.TouchUsers(ctx, batch); err != nil {
+		t.mu.Lock()
+		for id, at := range batch {
+			if newer, ok := t.pending[id]; !ok || newer.Before(at) {
+				t.pending[id] = at
+			}
+		}
+		t.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Start writes pending activity every interval until Stop is called.
+func (t *ActivityTracker) Start() {
+	go func() {
+		defer close(t.done)
+
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+			case <-t.flush:
+			}
+			if err := t.Flush(context.Background()); err != nil {
+				log.Printf("Failed to record user activity: %v", err)
+			}
+		}
+	}()
+}
+
+// Stop ends the background writes started by Start and writes the last batch.
+// It must only be called once, after Start.
+func (t *ActivityTracker) Stop(ctx context.Context) error {
+	close(t.stop)
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.Flush(ctx)
+}
+
+// TrackActivity is a middleware that marks the authenticated user as active.
+// API keys are not users; their use is recorded in api_keys.last_used_at instead.
+// It must run after Authenticate.
+func TrackActivity(t *ActivityTracker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if claims, ok := ClaimsFromContext(r.Context()); ok && !claims.IsAPIKey() {
+				t.Touch(claims.UserID)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
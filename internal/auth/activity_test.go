@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeActivity records the batches written by an ActivityTracker.
+type fakeActivity struct {
+	mu      sync.Mutex
+	batches []map[string]time.Time
+	err     error
+}
+
+func (f *fakeActivity) TouchUsers(_ context.Context, seen map[string]time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.batches = append(f.batches, seen)
+	return nil
+}
+
+func (f *fakeActivity) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.batches)
+}
+
+func TestActivityTracker_Batches(t *testing.T) {
+	store := &fakeActivity{}
+	tracker := NewActivityTracker(store, time.Hour)
+
+	for i := 0; i < 100; i++ {
+		tracker.Touch("alice")
+	}
+	tracker.Touch("bob")
+
+	if err := tracker.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.batches) != 1 || len(store.batches[0]) != 2 {
+		t.Fatalf("Expected one batch of 2 users, got %v", store.batches)
+	}
+
+	// Nothing pending, nothing written
+	if err := tracker.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.batches) != 1 {
+		t.Errorf("Expected no empty batch, got %d batches", len(store.batches))
+	}
+}
+
+func TestActivityTracker_RetriesFailedBatch(t *testing.T) {
+	store := &fakeActivity{err: errors.New("database down")}
+	tracker := NewActivityTracker(store, time.Hour)
+
+	tracker.Touch("alice")
+	if err := tracker.Flush(context.Background()); err == nil {
+		t.Fatal("Expected the flush to fail")
+	}
+
+	store.err = nil
+	tracker.Touch("bob")
+	if err := tracker.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.batches) != 1 || len(store.batches[0]) != 2 {
+		t.Errorf("Expected the failed batch to be retried with the next, got %v", store.batches)
+	}
+}
+
+func TestActivityTracker_StopFlushes(t *testing.T) {
+	store := &fakeActivity{}
+	tracker := NewActivityTracker(store, time.Hour)
+	tracker.Start()
+
+	tracker.Touch("alice")
+	if err := tracker.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if store.count() != 1 {
+		t.Errorf("Expected the last batch to be written on stop, got %d batches", store.count())
+	}
+}
+
+func TestActivityTracker_FlushesWhenFull(t *testing.T) {
+	store := &fakeActivity{}
+	tracker := NewActivityTracker(store, time.Hour)
+	tracker.Start()
+	defer func() { _ = tracker.Stop(context.Background()) }()
+
+	for i := 0; i < maxPendingActivity; i++ {
+		tracker.Touch(strconv.Itoa(i))
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for store.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if store.count() == 0 {
+		t.Error("Expected a full tracker to flush before the interval")
+	}
+}
+
+func TestTrackActivity(t *testing.T) {
+	store := &fakeActivity{}
+	tracker := NewActivityTracker(store, time.Hour)
+	handler := TrackActivity(tracker)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// Anonymous requests are not tracked
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(WithClaims(req.Context(), &Claims{UserID: "alice"})))
+
+	_ = tracker.Flush(context.Background())
+	if len(store.batches) != 1 || len(store.batches[0]) != 1 {
+		t.Fatalf("Expected only the authenticated user, got %v", store.batches)
+	}
+	if _, ok := store.batches[0]["alice"]; !ok {
+		t.Errorf("Expected alice to be tracked, got %v", store.batches[0])
+	}
+}
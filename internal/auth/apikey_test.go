@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeAPIKeys is an in-memory APIKeyResolver keyed by hash.
+type fakeAPIKeys map[string]*Claims
+
+func (f fakeAPIKeys) ResolveAPIKey(_ context.Context, _, hash string) (*Claims, error) {
+	if c, ok := f[hash]; ok {
+		return c, nil
+	}
+	return nil, ErrInvalidAPIKey
+}
+
+func TestNewAPIKey(t *testing.T) {
+	key, prefix, err := NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, prefix+"_") || len(key) != len("sal_")+8+1+64 {
+		t.Errorf("Unexpected key %q with prefix %q", key, prefix)
+	}
+
+	parsed, err := ParseAPIKey(key)
+	if err != nil || parsed != prefix {
+		t.Errorf("Expected prefix %q, got %q (%v)", prefix, parsed, err)
+	}
+
+	other, _, _ := NewAPIKey()
+	if other == key {
+		t.Error("Expected distinct keys")
+	}
+}
+
+func TestParseAPIKey_Malformed(t *testing.T) {
+	secret := strings.Repeat("ab", 32)
+	for _, key := range []string{
+		"",
+		"eyJhbGciOiJIUzI1NiJ9.e30.sig",
+		"sal_",
+		"sal_0123abcd",
+		"sal_0123abcd_" + secret[:10],
+		"sal_0123ABCD_" + secret,
+		"sal_0123abcd_" + strings.Repeat("zz", 32),
+		"sal_0123abc_" + secret + "a",
+	} {
+		if _, err := ParseAPIKey(key); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("Expected ErrInvalidAPIKey for %q, got %v", key, err)
+		}
+	}
+}
+
+func TestVerifier_APIKeys(t *testing.T) {
+	key, _, _ := NewAPIKey()
+	unknown, _, _ := NewAPIKey()
+	grant := &Claims{OrgID: "org-1", APIKeyID: "key-1", Capabilities: []string{"notes.read"}}
+
+	jwtOnly := NewVerifier(testKeys, fakeRevocations{}, fakeSuspensions{})
+	v := jwtOnly.WithAPIKeys(fakeAPIKeys{HashAPIKey(key): grant})
+
+	// 1. Accepted by the Verifier resolving keys
+	claims, err := v.Verify(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !claims.IsAPIKey() || claims.OrgID != "org-1" || !claims.HasCapability("notes.read") || claims.HasCapability("notes.create") {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	// 2. Unknown keys
+	if _, err := v.Verify(context.Background(), unknown); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Expected ErrInvalidAPIKey, got %v", err)
+	}
+
+	// 3. The original Verifier still refuses keys, and both accept access tokens
+	if _, err := jwtOnly.Verify(context.Background(), key); !errors.Is(err, ErrAPIKeysNotAccepted) {
+		t.Errorf("Expected ErrAPIKeysNotAccepted, got %v", err)
+	}
+	token, _ := SignAccessToken(Claims{UserID: "user-1", SessionID: "live-session"}, testKeys)
+	if _, err := v.Verify(context.Background(), token); err != nil {
+		t.Errorf("Expected access tokens to be accepted, got %v", err)
+	}
+}
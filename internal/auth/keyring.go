@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms supported by KeyRing.
+const (
+	// AlgHS256 is HMAC-SHA256 with a shared secret. Anyone able to verify can also sign,
+	// so it is only meant for development and tests.
+	AlgHS256 = "HS256"
+	// AlgRS256 is RSASSA-PKCS1-v1_5 with SHA-256.
+	AlgRS256 = "RS256"
+	// AlgEdDSA is Ed25519.
+	AlgEdDSA = "EdDSA"
+)
+
+// minRSABits is the smallest accepted RSA modulus.
+const minRSABits = 2048
+
+// ErrUnknownKey is returned when a token names a key ("kid") the key ring does not hold.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// SigningKey is a key of a KeyRing, identified by the "kid" header of the tokens it signs.
+// Keys loaded from a public key can only verify.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+
+	private interface{} // ed25519.PrivateKey, *rsa.PrivateKey or []byte; nil for verification-only keys
+	public  interface{} // ed25519.PublicKey, *rsa.PublicKey or []byte
+}
+
+// CanSign reports whether the key holds private material.
+func (k SigningKey) CanSign() bool {
+	return k.private != nil
+}
+
+// NewHMACKey creates an HS256 key from a shared secret.
+func NewHMACKey(id, secret string) SigningKey {
+	return SigningKey{ID: id, Algorithm: AlgHS256, private: []byte(secret), public: []byte(secret)}
+}
+
+// ParseKeyPEM parses a PEM encoded private key (PKCS#8 or PKCS#1) or public key (PKIX).
+// RSA keys sign with RS256, Ed25519 keys with EdDSA.
+func ParseKeyPEM(id string, data []byte) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, fmt.Errorf("key %q: no PEM data", id)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return SigningKey{}, fmt.Errorf("key %q: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	key := SigningKey{ID: id}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.private, key.public = AlgRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Algorithm, key.public = AlgRS256, k
+	case ed25519.PrivateKey:
+		key.Algorithm, key.private, key.public = AlgEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Algorithm, key.public = AlgEdDSA, k
+	default:
+		return SigningKey{}, fmt.Errorf("key %q: unsupported key type %T", id, parsed)
+	}
+
+	if pub, ok := key.public.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSABits {
+		return SigningKey{}, fmt.Errorf("key %q: RSA keys must have at least %d bits", id, minRSABits)
+	}
+	return key, nil
+}
+
+// KeyRing signs tokens with its active key and verifies them with any of its keys, selected by "kid".
+//
+// Keys are rotated without downtime by first adding the new key as a retired (verification) key,
+// so it is published in the JWKS before any token uses it, then making it active, and finally
+// removing the old key once every token it signed has expired.
+type KeyRing struct {
+	active SigningKey
+	keys   map[string]SigningKey
+}
+
+// NewKeyRing creates a key ring signing with the key activeID. The other keys only verify.
+func NewKeyRing(activeID string, keys ...SigningKey) (*KeyRing, error) {
+	ring := &KeyRing{keys: make(map[string]SigningKey, len(keys))}
+	for _, k := range keys {
+		if k.ID == "" {
+			return nil, errors.New("signing key without id")
+		}
+		if _, ok := ring.keys[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate signing key %q", k.ID)
+		}
+		ring.keys[k.ID] = k
+	}
+
+	active, ok := ring.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active signing key %q not found", activeID)
+	}
+	if !active.CanSign() {
+		return nil, fmt.Errorf("active signing key %q has no private key", activeID)
+	}
+	ring.active = active
+	return ring, nil
+}
+
+// NewHMACKeyRing creates a key ring with a single HS256 key.
+func NewHMACKeyRing(secret string) *KeyRing {
+	key := NewHMACKey("default", secret)
+	return &KeyRing{active: key, keys: map[string]SigningKey{key.ID: key}}
+}
+
+// LoadKeyRing loads every "<kid>.pem" file in dir and signs with activeID.
+func LoadKeyRing(dir, activeID string) (*KeyRing, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+
+	keys := make([]SigningKey, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key: %w", err)
+		}
+		key, err := ParseKeyPEM(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeyRing(activeID, keys...)
+}
+
+// ActiveKeyID returns the id of the key new tokens are signed with.
+func (k *KeyRing) ActiveKeyID() string {
+	return k.active.ID
+}
+
+// sign signs claims with the active key.
+func (k *KeyRing) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(k.active.Algorithm), claims)
+	token.Header["kid"] = k.active.ID
+	return token.SignedString(k.active.private)
+}
+
+// keyFunc selects the verification key named by the token's "kid", or the active key if it has none.
+// The token's algorithm must be the key's, so a public key can never be used as an HMAC secret.
+func (k *KeyRing) keyFunc(t *jwt.Token) (interface{}, error) {
+	key := k.active
+	if kid, ok := t.Header["kid"].(string); ok {
+		if key, ok = k.keys[kid]; !ok {
+			return nil, ErrUnknownKey
+		}
+	}
+
+	if t.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+	return key.public, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // OKP curve
+	X   string `json:"x,omitempty"`   // OKP public key
+}
+
+// JWKSet is a JSON Web Key Set, as served at /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the ring, active and retired, sorted by id.
+// HMAC keys are secret and never published.
+func (k *KeyRing) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range k.keys {
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: AlgRS256,
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: AlgEdDSA,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
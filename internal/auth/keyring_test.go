@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testKeys is the HS256 key ring shared by the token tests.
+var testKeys = NewHMACKeyRing("secret")
+
+// pemKey encodes a private or public key as PEM.
+func pemKey(t *testing.T, key interface{}) []byte {
+	t.Helper()
+	var block *pem.Block
+	switch k := key.(type) {
+	case ed25519.PublicKey, *rsa.PublicKey:
+		der, err := x509.MarshalPKIXPublicKey(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	return pem.EncodeToMemory(block)
+}
+
+// newEdKey generates an Ed25519 signing key.
+func newEdKey(t *testing.T, id string) SigningKey {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseKeyPEM(id, pemKey(t, priv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestKeyRing_Algorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := ParseKeyPEM("rs", pemKey(t, rsaKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []SigningKey{rs, newEdKey(t, "ed"), NewHMACKey("hs", "secret")} {
+		t.Run(key.Algorithm, func(t *testing.T) {
+			ring, err := NewKeyRing(key.ID, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := NewAccessToken("user-1", "org-1", "admin", ring)
+			if err != nil {
+				t.Fatalf("NewAccessToken failed: %v", err)
+			}
+
+			parsed, _, _ := jwt.NewParser().ParseUnverified(token, &Claims{})
+			if parsed.Header["kid"] != key.ID || parsed.Method.Alg() != key.Algorithm {
+				t.Errorf("Expected kid %q with %s, got %v", key.ID, key.Algorithm, parsed.Header)
+			}
+
+			claims, err := ParseAccessToken(token, ring)
+			if err != nil || claims.UserID != "user-1" {
+				t.Fatalf("ParseAccessToken failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyRing_Rotation(t *testing.T) {
+	oldKey, newKey := newEdKey(t, "2024-01"), newEdKey(t, "2024-02")
+
+	before, _ := NewKeyRing("2024-01", oldKey)
+	during, _ := NewKeyRing("2024-02", oldKey, newKey)
+	after, _ := NewKeyRing("2024-02", newKey)
+
+	oldToken, _ := NewAccessToken("user-1", "", "", before)
+	newToken, _ := NewAccessToken("user-1", "", "", during)
+
+	// 1. Tokens of the retired key stay valid while it is in the ring
+	if _, err := ParseAccessToken(oldToken, during); err != nil {
+		t.Errorf("Expected retired key to verify, got %v", err)
+	}
+	// 2. Instances not rotated yet already accept new tokens once the key is published to them
+	if _, err := ParseAccessToken(newToken, during); err != nil {
+		t.Errorf("Expected active key to verify, got %v", err)
+	}
+	// 3. Once removed, its tokens are rejected
+	if _, err := ParseAccessToken(oldToken, after); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestKeyRing_AlgorithmConfusion(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pub, _ := ParseKeyPEM("rs", pemKey(t, &rsaKey.PublicKey))
+	priv, _ := ParseKeyPEM("rs", pemKey(t, rsaKey))
+	ring, _ := NewKeyRing("rs", priv)
+
+	// An HS256 token "signed" with the public key must not verify against it
+	claims := Claims{UserID: "attacker"}
+	claims.Issuer = Issuer
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = "rs"
+	token, err := forged.SignedString(pemKey(t, pub.public))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseAccessToken(token, ring); err == nil {
+		t.Error("Expected HS256 token to be rejected by an RSA key")
+	}
+}
+
+func TestNewKeyRing_Invalid(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	publicOnly, err := ParseKeyPEM("pub", pemKey(t, priv.Public()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if publicOnly.CanSign() {
+		t.Error("Expected public key to be verification-only")
+	}
+
+	if _, err := NewKeyRing("pub", publicOnly); err == nil {
+		t.Error("Expected a verification-only active key to be rejected")
+	}
+	if _, err := NewKeyRing("missing", publicOnly); err == nil {
+		t.Error("Expected a missing active key to be rejected")
+	}
+	if _, err := NewKeyRing("a", newEdKey(t, "a"), newEdKey(t, "a")); err == nil {
+		t.Error("Expected duplicate key ids to be rejected")
+	}
+
+	weak, _ := rsa.GenerateKey(rand.Reader, 1024)
+	if _, err := ParseKeyPEM("weak", pemKey(t, weak)); err == nil {
+		t.Error("Expected a 1024 bit RSA key to be rejected")
+	}
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	dir := t.TempDir()
+	_, active, _ := ed25519.GenerateKey(rand.Reader)
+	_, retired, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	files := map[string][]byte{
+		"2024-03.pem": pemKey(t, active),
+		"2024-02.pem": pemKey(t, retired.Public()), // Private half already destroyed
+		"2024-01.pem": pemKey(t, rsaKey),
+		"README.txt":  []byte("ignored"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ring, err := LoadKeyRing(dir, "2024-03")
+	if err != nil {
+		t.Fatalf("LoadKeyRing failed: %v", err)
+	}
+	if ring.ActiveKeyID() != "2024-03" {
+		t.Errorf("Expected active key 2024-03, got %s", ring.ActiveKeyID())
+	}
+
+	set := ring.JWKS()
+	if len(set.Keys) != 3 {
+		t.Fatalf("Expected 3 published keys, got %d", len(set.Keys))
+	}
+	if set.Keys[0].Kid != "2024-01" || set.Keys[0].Kty != "RSA" || set.Keys[0].N == "" {
+		t.Errorf("Unexpected RSA JWK: %+v", set.Keys[0])
+	}
+	if set.Keys[2].Kty != "OKP" || set.Keys[2].Crv != "Ed25519" || set.Keys[2].Alg != AlgEdDSA {
+		t.Errorf("Unexpected Ed25519 JWK: %+v", set.Keys[2])
+	}
+}
+
+func TestJWKS_HMACNotPublished(t *testing.T) {
+	if keys := testKeys.JWKS().Keys; len(keys) != 0 {
+		t.Errorf("Expected HMAC secrets to stay private, got %+v", keys)
+	}
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// LockoutPolicy controls progressive account lockout after failed logins.
+type LockoutPolicy struct {
+	Threshold int           // Failed attempts allowed before the first lock
+	BaseDelay time.Duration // Lock duration once the threshold is reached
+	MaxDelay  time.Duration // Upper bound for the lock duration
+}
+
+// LockDuration returns how long to lock an account after the given number of consecutive failures.
+// It is zero below the threshold and doubles with every failure past it, capped at MaxDelay (if set).
+func (p LockoutPolicy) LockDuration(failures int) time.Duration {
+	if p.Threshold <= 0 || failures < p.Threshold {
+		return 0
+	}
+
+	d := p.BaseDelay
+	for i := p.Threshold; i < failures; i++ {
+		d *= 2
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
+
+// maxTrackedKeys bounds the FailureTracker; stale entries are pruned once it is reached.
+const maxTrackedKeys = 10000
+
+// failureEntry is the in-memory counterpart of users.failed_login_attempts / locked_until.
+type failureEntry struct {
+	failures    int
+	lockedUntil time.Time
+	lastFailure time.Time
+}
+
+// FailureTracker applies a LockoutPolicy to keys that have no database row, such as unknown emails.
+// Locking unknown emails exactly like real accounts keeps the lockout response from revealing
+// whether an email is registered.
+type FailureTracker struct {
+	policy LockoutPolicy
+
+	mu      sync.Mutex
+	entries map[string]*failureEntry
+}
+
+// NewFailureTracker creates a FailureTracker for the given policy.
+func NewFailureTracker(policy LockoutPolicy) *FailureTracker {
+	return &FailureTracker{policy: policy, entries: make(map[string]*failureEntry)}
+}
+
+// LockedUntil returns when the lock on key expires, or the zero time if it is not locked.
+func (t *FailureTracker) LockedUntil(key string) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if e, ok := t.entries[normalizeKey(key)]; ok && time.Now().Before(e.lockedUntil) {
+		return e.lockedUntil
+	}
+	return time.Time{}
+}
+
+// Fail records a failed attempt for key and returns when the resulting lock expires
+// (the zero time if the key is not locked).
+func (t *FailureTracker) Fail(key string) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if len(t.entries) >= maxTrackedKeys {
+		t.prune(now)
+	}
+
+	key = normalizeKey(key)
+	e, ok := t.entries[key]
+	if !ok {
+		e = &failureEntry{}
+		t.entries[key] = e
+	}
+	e.failures++
+	e.lastFailure = now
+	if d := t.policy.LockDuration(e.failures); d > 0 {
+		e.lockedUntil = now.Add(d)
+	}
+	return e.lockedUntil
+}
+
+// prune drops entries that are unlocked and idle for longer than the maximum lock.
+// The caller must hold t.mu.
+func (t *FailureTracker) prune(now time.Time) {
+	for k, e := range t.entries {
+		if now.After(e.lockedUntil) && now.Sub(e.lastFailure) > t.policy.MaxDelay {
+			delete(t.entries, k)
+		}
+	}
+}
+
+// normalizeKey makes keys case-insensitive, matching email lookups.
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutPolicy_LockDuration(t *testing.T) {
+	p := LockoutPolicy{Threshold: 3, BaseDelay: time.Minute, MaxDelay: 5 * time.Minute}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Minute},
+		{4, 2 * time.Minute},
+		{5, 4 * time.Minute},
+		{6, 5 * time.Minute}, // capped
+		{50, 5 * time.Minute},
+	}
+
+	for _, tc := range tests {
+		if got := p.LockDuration(tc.failures); got != tc.want {
+			t.Errorf("LockDuration(%d) = %s, want %s", tc.failures, got, tc.want)
+		}
+	}
+}
+
+func TestLockoutPolicy_Disabled(t *testing.T) {
+	p := LockoutPolicy{Threshold: 0, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	if got := p.LockDuration(100); got != 0 {
+		t.Errorf("Expected no lock when threshold is 0, got %s", got)
+	}
+}
+
+func TestFailureTracker(t *testing.T) {
+	tracker := NewFailureTracker(LockoutPolicy{Threshold: 2, BaseDelay: time.Minute, MaxDelay: time.Hour})
+
+	if !tracker.Fail("Ghost@Example.com").IsZero() {
+		t.Error("Expected no lock after the first failure")
+	}
+	if tracker.Fail("ghost@example.com").IsZero() {
+		t.Error("Expected a lock once the threshold is reached (keys are case-insensitive)")
+	}
+	if tracker.LockedUntil("GHOST@example.com").IsZero() {
+		t.Error("Expected LockedUntil to report the lock")
+	}
+	if !tracker.LockedUntil("other@example.com").IsZero() {
+		t.Error("Expected other keys to be unaffected")
+	}
+}
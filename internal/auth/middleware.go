@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// contextKey is an unexported type for context keys defined in this package.
+type contextKey int
+
+// claimsKey is the context key holding the authenticated *Claims.
+const claimsKey contextKey = iota
+
+// Authenticate is a middleware that requires a valid "Authorization: Bearer <token>" header.
+// The verified claims are stored in the request context; read them with ClaimsFromContext.
+func Authenticate(v *Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := BearerToken(r)
+			if token == "" {
+				unauthorized(w, "Missing bearer token")
+				return
+			}
+
+			claims, err := v.Verify(r.Context(), token)
+			if err != nil {
+				switch {
+				case errors.Is(err, jwt.ErrTokenExpired):
+					unauthorized(w, "Token expired")
+				case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+					unauthorized(w, "Invalid token issuer")
+				case errors.Is(err, ErrTokenRevoked):
+					unauthorized(w, "Session has been revoked")
+				case errors.Is(err, ErrInvalidAPIKey):
+					unauthorized(w, "Invalid API key")
+				case errors.Is(err, ErrAPIKeysNotAccepted):
+					unauthorized(w, "API keys are not accepted here")
+				case errors.Is(err, ErrOrgSuspended):
+					response.ErrorCode(w, http.StatusForbidden, CodeOrgSuspended, "Organization is suspended")
+				case errors.Is(err, jwt.ErrTokenMalformed),
+					errors.Is(err, jwt.ErrTokenSignatureInvalid),
+					errors.Is(err, jwt.ErrTokenUnverifiable),
+					errors.Is(err, jwt.ErrTokenInvalidClaims),
+					errors.Is(err, jwt.ErrTokenRequiredClaimMissing),
+					errors.Is(err, ErrTokenPurpose),
+					errors.Is(err, ErrMissingSession):
+					unauthorized(w, "Invalid token")
+				default:
+					response.Error(w, http.StatusInternalServerError, "Failed to verify token")
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+		})
+	}
+}
+
+// ImpersonationDeniedMessage is the error of actions not allowed while impersonating.
+const ImpersonationDeniedMessage = "Not allowed while impersonating"
+
+// DenyImpersonation is a middleware that refuses impersonation tokens with 403.
+// Use it on destructive or account-changing routes; it must run after Authenticate.
+func DenyImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if MustClaims(r.Context()).Impersonated() {
+			response.Error(w, http.StatusForbidden, ImpersonationDeniedMessage)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// DenyAPIKeys is a middleware that refuses API keys with 403, for routes acting as a user.
+// It must run after Authenticate.
+func DenyAPIKeys(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if MustClaims(r.Context()).IsAPIKey() {
+			response.Error(w, http.StatusForbidden, "Not allowed with an API key")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WithClaims returns a copy of ctx carrying the given claims.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// ClaimsFromContext returns the claims stored by Authenticate, if any.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*Claims)
+	return claims, ok && claims != nil
+}
+
+// MustClaims returns the claims stored by Authenticate.
+// It panics if the route is not behind Authenticate, which is a programming error.
+func MustClaims(ctx context.Context) *Claims {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		panic("auth: no claims in context; is the route behind auth.Authenticate?")
+	}
+	return claims
+}
+
+// MustUserID returns the authenticated user's ID.
+// It panics if the route is not behind Authenticate, which is a programming error.
+func MustUserID(ctx context.Context) string {
+	return MustClaims(ctx).UserID
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer <token>" header.
+// It returns an empty string if the header is missing or uses another scheme.
+func BearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// unauthorized sends a 401 with a WWW-Authenticate challenge.
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="sal-api"`)
+	response.Error(w, http.StatusUnauthorized, message)
+}
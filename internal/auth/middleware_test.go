@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// serveAuthenticated runs a request with the given Authorization header through Authenticate.
+func serveAuthenticated(t *testing.T, header string) (*httptest.ResponseRecorder, *Claims) {
+	t.Helper()
+
+	var seen *Claims
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = ClaimsFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	v := NewVerifier(testKeys, fakeRevocations{"revoked-session": true}, fakeSuspensions{})
+	req := httptest.NewRequest("GET", "/", nil)
+	if header != "" {
+		req.Header.Set("Authorization", header)
+	}
+	rr := httptest.NewRecorder()
+	Authenticate(v)(next).ServeHTTP(rr, req)
+	return rr, seen
+}
+
+// signRaw signs arbitrary claims with HS256, bypassing SignAccessToken defaults.
+func signRaw(t *testing.T, claims Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// errorMessage decodes the message of a response.Error body.
+func errorMessage(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode error body: %v", err)
+	}
+	return body.Data["message"]
+}
+
+func TestAuthenticate_Valid(t *testing.T) {
+	token, _ := SignAccessToken(Claims{UserID: "user-1", OrgID: "org-1", Role: "admin", SessionID: "live-session"}, testKeys)
+
+	rr, claims := serveAuthenticated(t, "Bearer "+token)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	if claims == nil || claims.UserID != "user-1" || claims.OrgID != "org-1" || claims.Role != "admin" {
+		t.Errorf("Unexpected claims in context: %+v", claims)
+	}
+}
+
+func TestAuthenticate_Rejections(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	expired := signRaw(t, Claims{
+		UserID:    "user-1",
+		SessionID: "live-session",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(past),
+			Issuer:    Issuer,
+		},
+	})
+	wrongIssuer := signRaw(t, Claims{
+		UserID:    "user-1",
+		SessionID: "live-session",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			Issuer:    "someone-else",
+		},
+	})
+	revoked, _ := SignAccessToken(Claims{UserID: "user-1", SessionID: "revoked-session"}, testKeys)
+	challenge, _ := SignChallengeToken(Claims{UserID: "user-1", SessionID: "live-session"}, PurposeMFA, testKeys)
+	enroll, _ := SignPurposeToken(Claims{UserID: "user-1", SessionID: "live-session"}, PurposeMFAEnroll, time.Minute, testKeys)
+
+	tests := []struct {
+		name    string
+		header  string
+		message string
+	}{
+		{"Missing Header", "", "Missing bearer token"},
+		{"Wrong Scheme", "Basic dXNlcjpwYXNz", "Missing bearer token"},
+		{"Malformed", "Bearer not-a-jwt", "Invalid token"},
+		{"Expired", "Bearer " + expired, "Token expired"},
+		{"Wrong Issuer", "Bearer " + wrongIssuer, "Invalid token issuer"},
+		{"Revoked", "Bearer " + revoked, "Session has been revoked"},
+		{"MFA Challenge Token", "Bearer " + challenge, "Invalid token"},
+		{"MFA Enroll Token", "Bearer " + enroll, "Invalid token"},
+		{"API Key", "Bearer sal_0123abcd_" + strings.Repeat("ab", 32), "API keys are not accepted here"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rr, claims := serveAuthenticated(t, tc.header)
+			if rr.Code != http.StatusUnauthorized {
+				t.Fatalf("Expected status 401, got %d", rr.Code)
+			}
+			if claims != nil {
+				t.Error("Next handler should not have been called")
+			}
+			if msg := errorMessage(t, rr); msg != tc.message {
+				t.Errorf("Expected message %q, got %q", tc.message, msg)
+			}
+		})
+	}
+}
+
+func TestMustUserID_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected MustUserID to panic without claims")
+		}
+	}()
+	MustUserID(httptest.NewRequest("GET", "/", nil).Context())
+}
+
+func TestDenyImpersonation(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for _, claims := range []*Claims{
+		{UserID: "user-1"},
+		{UserID: "user-1", Actor: &Actor{UserID: "admin-1"}},
+	} {
+		req := httptest.NewRequest("POST", "/", nil)
+		rr := httptest.NewRecorder()
+		DenyImpersonation(next).ServeHTTP(rr, req.WithContext(WithClaims(req.Context(), claims)))
+
+		want := http.StatusNoContent
+		if claims.Impersonated() {
+			want = http.StatusForbidden
+		}
+		if rr.Code != want {
+			t.Errorf("Impersonated %t: expected %d, got %d", claims.Impersonated(), want, rr.Code)
+		}
+	}
+}
+
+func TestDenyAPIKeys(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for _, claims := range []*Claims{
+		{UserID: "user-1"},
+		{OrgID: "org-1", APIKeyID: "key-1"},
+	} {
+		req := httptest.NewRequest("POST", "/", nil)
+		rr := httptest.NewRecorder()
+		DenyAPIKeys(next).ServeHTTP(rr, req.WithContext(WithClaims(req.Context(), claims)))
+
+		want := http.StatusNoContent
+		if claims.IsAPIKey() {
+			want = http.StatusForbidden
+		}
+		if rr.Code != want {
+			t.Errorf("API key %t: expected %d, got %d", claims.IsAPIKey(), want, rr.Code)
+		}
+	}
+}
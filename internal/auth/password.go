@@ -2,26 +2,188 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword hashes a plain text password using bcrypt with a default cost.
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+// Password hashing algorithms.
+const (
+	// AlgArgon2id is argon2id (RFC 9106), stored in the PHC string format:
+	// $argon2id$v=19$m=<memory KiB>,t=<iterations>,p=<parallelism>$<salt>$<key>
+	AlgArgon2id = "argon2id"
+	// AlgBcrypt is bcrypt, stored in its modular crypt format ($2a$<cost>$...).
+	AlgBcrypt = "bcrypt"
+)
+
+// ErrInvalidPassword is returned when a password does not match its hash.
+var ErrInvalidPassword = errors.New("invalid password")
+
+// Argon2Params are the argon2id cost parameters.
+type Argon2Params struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLen     uint32
+	KeyLen      uint32
+}
+
+// PasswordHasher hashes new passwords with its configured algorithm and verifies hashes of
+// any supported algorithm. The format of a hash records its algorithm and parameters, so they can
+// be changed at any time: existing hashes keep working and NeedsRehash reports them for upgrade.
+type PasswordHasher struct {
+	Algorithm  string // AlgArgon2id or AlgBcrypt
+	Argon2     Argon2Params
+	BcryptCost int
+}
+
+// DefaultPasswordHasher returns argon2id with the OWASP recommended minimum
+// (19 MiB, 2 iterations, 1 lane), which takes tens of milliseconds per hash.
+func DefaultPasswordHasher() PasswordHasher {
+	return PasswordHasher{
+		Algorithm: AlgArgon2id,
+		Argon2: Argon2Params{
+			Memory:      19 * 1024,
+			Iterations:  2,
+			Parallelism: 1,
+			SaltLen:     16,
+			KeyLen:      32,
+		},
+		BcryptCost: 12,
+	}
+}
+
+// Validate checks that the parameters are secure, and bounded so a login stays well under a second.
+func (h PasswordHasher) Validate() error {
+	switch h.Algorithm {
+	case AlgArgon2id:
+		p := h.Argon2
+		if p.Memory < 8*1024 || p.Memory > 1024*1024 {
+			return fmt.Errorf("argon2 memory must be between 8 MiB and 1 GiB, got %d KiB", p.Memory)
+		}
+		if p.Iterations < 1 || p.Iterations > 10 {
+			return fmt.Errorf("argon2 iterations must be between 1 and 10, got %d", p.Iterations)
+		}
+		if p.Parallelism < 1 || p.Parallelism > 16 {
+			return fmt.Errorf("argon2 parallelism must be between 1 and 16, got %d", p.Parallelism)
+		}
+		if p.SaltLen < 16 || p.KeyLen < 16 {
+			return fmt.Errorf("argon2 salt and key must be at least 16 bytes")
+		}
+	case AlgBcrypt:
+		if h.BcryptCost < 10 || h.BcryptCost > 14 {
+			return fmt.Errorf("bcrypt cost must be between 10 and 14, got %d", h.BcryptCost)
+		}
+	default:
+		return fmt.Errorf("unsupported password hash algorithm %q", h.Algorithm)
+	}
+	return nil
+}
+
+// Hash hashes a plain text password with the configured algorithm.
+func (h PasswordHasher) Hash(password string) (string, error) {
+	if h.Algorithm == AlgBcrypt {
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash password: %w", err)
+		}
+		return string(bytes), nil
+	}
+
+	p := h.Argon2
+	salt := make([]byte, p.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
 		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
-	return string(bytes), nil
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// NeedsRehash reports whether hash was made with another algorithm or other parameters than configured.
+// Callers rehash the password after the next successful check.
+func (h PasswordHasher) NeedsRehash(hash string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		if h.Algorithm != AlgArgon2id {
+			return true
+		}
+		p, _, _, err := decodeArgon2(hash)
+		return err != nil || p.Memory != h.Argon2.Memory || p.Iterations != h.Argon2.Iterations ||
+			p.Parallelism != h.Argon2.Parallelism || p.KeyLen != h.Argon2.KeyLen
+	}
+
+	if h.Algorithm != AlgBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.BcryptCost
+}
+
+// HashPassword hashes a plain text password with the default hasher.
+func HashPassword(password string) (string, error) {
+	return DefaultPasswordHasher().Hash(password)
 }
 
-// CheckPasswordHash compares a bcrypt hashed password with a plain text password.
+// CheckPasswordHash compares a hashed password (argon2id or bcrypt) with a plain text password.
 // Returns nil if the passwords match, or an error if they don't.
 func CheckPasswordHash(password, hash string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	if err != nil {
-		return fmt.Errorf("invalid password: %w", err)
+	if strings.HasPrefix(hash, "$argon2id$") {
+		p, salt, key, err := decodeArgon2(hash)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPassword, err)
+		}
+		other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLen)
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return ErrInvalidPassword
+		}
+		return nil
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPassword, err)
 	}
 	return nil
 }
+
+// decodeArgon2 parses an argon2id PHC string.
+func decodeArgon2(hash string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgArgon2id {
+		return p, nil, nil, errors.New("malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, fmt.Errorf("malformed argon2id parameters: %w", err)
+	}
+	// Guard against hashes that would make verification run away
+	if p.Memory == 0 || p.Memory > 4*1024*1024 || p.Iterations == 0 || p.Iterations > 100 || p.Parallelism == 0 {
+		return p, nil, nil, errors.New("argon2id parameters out of range")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, errors.New("malformed argon2id key")
+	}
+
+	p.SaltLen, p.KeyLen = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"bufio"
+	_ "embed" // common_passwords.txt
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Password validator tags. Each rule is its own tag so the response can say which one failed
+// (see response.ValidationError).
+const (
+	TagPasswordLength   = "password_length"
+	TagUppercase        = "uppercase"
+	TagLowercase        = "lowercase"
+	TagNumber           = "number"
+	TagSpecial          = "special"
+	TagCommonPassword   = "common_password"
+	TagPersonalPassword = "personal_password"
+
+	// TagPassword is an alias for every rule that can be checked on the field alone.
+	// Use it as `validate:"required,password"`.
+	TagPassword = "password"
+)
+
+// PasswordPolicy describes what a new password must satisfy.
+// It applies wherever a password is chosen: registration, reset and change.
+type PasswordPolicy struct {
+	MinLength      int
+	MaxLength      int // bcrypt ignores input beyond 72 bytes
+	RequireUpper   bool
+	RequireLower   bool
+	RequireNumber  bool
+	RequireSpecial bool
+	RejectCommon   bool // Reject passwords on the embedded common-password list
+	RejectPersonal bool // Reject passwords containing the user's email or organization name
+}
+
+// DefaultPasswordPolicy returns the policy used when nothing is configured.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:      8,
+		MaxLength:      72,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireNumber:  true,
+		RequireSpecial: true,
+		RejectCommon:   true,
+		RejectPersonal: true,
+	}
+}
+
+// RegisterValidators registers the password tags and the "password" alias on v.
+func (p PasswordPolicy) RegisterValidators(v *validator.Validate) error {
+	rules := map[string]func(string) bool{
+		TagPasswordLength: p.lengthOK,
+		TagUppercase:      func(s string) bool { return !p.RequireUpper || strings.IndexFunc(s, unicode.IsUpper) >= 0 },
+		TagLowercase:      func(s string) bool { return !p.RequireLower || strings.IndexFunc(s, unicode.IsLower) >= 0 },
+		TagNumber:         func(s string) bool { return !p.RequireNumber || strings.IndexFunc(s, unicode.IsDigit) >= 0 },
+		TagSpecial:        func(s string) bool { return !p.RequireSpecial || strings.IndexFunc(s, isSpecial) >= 0 },
+		TagCommonPassword: func(s string) bool { return !p.RejectCommon || !IsCommonPassword(s) },
+	}
+
+	for tag, ok := range rules {
+		err := v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return ok(fl.Field().String())
+		})
+		if err != nil {
+			return fmt.Errorf("failed to register %s validator: %w", tag, err)
+		}
+	}
+
+	v.RegisterAlias(TagPassword, strings.Join([]string{
+		TagPasswordLength, TagUppercase, TagLowercase, TagNumber, TagSpecial, TagCommonPassword,
+	}, ","))
+	return nil
+}
+
+// ContainsPersonal reports whether password contains the local part of email or one of the
+// organization names, which the policy forbids when RejectPersonal is set.
+// Parts shorter than 3 characters are ignored.
+func (p PasswordPolicy) ContainsPersonal(password, email string, orgNames ...string) bool {
+	if !p.RejectPersonal {
+		return false
+	}
+
+	lower := strings.ToLower(password)
+	local, _, _ := strings.Cut(strings.ToLower(email), "@")
+
+	candidates := []string{local}
+	for _, name := range orgNames {
+		name = strings.ToLower(strings.TrimSpace(name))
+		candidates = append(candidates, name, strings.ReplaceAll(name, " ", ""))
+	}
+
+	for _, c := range candidates {
+		if utf8.RuneCountInString(c) >= 3 && strings.Contains(lower, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// lengthOK checks the length bounds; MaxLength is in bytes since that is what bcrypt sees.
+func (p PasswordPolicy) lengthOK(s string) bool {
+	if utf8.RuneCountInString(s) < p.MinLength {
+		return false
+	}
+	return p.MaxLength <= 0 || len(s) <= p.MaxLength
+}
+
+// isSpecial reports whether r is neither a letter, a digit nor whitespace.
+func isSpecial(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+}
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords is the parsed common-password list.
+var commonPasswords = func() map[string]struct{} {
+	set := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswordsFile))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = struct{}{}
+	}
+	return set
+}()
+
+// IsCommonPassword reports whether password is on the embedded common-password list (case-insensitive).
+func IsCommonPassword(password string) bool {
+	_, ok := commonPasswords[strings.ToLower(password)]
+	return ok
+}
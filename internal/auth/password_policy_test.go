@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestPasswordPolicy_Validators(t *testing.T) {
+	v := validator.New()
+	if err := DefaultPasswordPolicy().RegisterValidators(v); err != nil {
+		t.Fatal(err)
+	}
+
+	type input struct {
+		Password string `validate:"password"`
+	}
+
+	tests := []struct {
+		password string
+		want     string // failing rule, "" if valid
+	}{
+		{"TestPass123!", ""},
+		{"Sh0rt!", TagPasswordLength},
+		{"testpass123!", TagUppercase},
+		{"TESTPASS123!", TagLowercase},
+		{"TestPassword!", TagNumber},
+		{"TestPass1234", TagSpecial},
+		{"P@ssw0rd1", TagCommonPassword},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.password, func(t *testing.T) {
+			err := v.Struct(input{Password: tc.password})
+			if tc.want == "" {
+				if err != nil {
+					t.Errorf("Expected valid, got %v", err)
+				}
+				return
+			}
+
+			var ve validator.ValidationErrors
+			if !errors.As(err, &ve) || ve[0].ActualTag() != tc.want {
+				t.Errorf("Expected %s to fail, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_Disabled(t *testing.T) {
+	v := validator.New()
+	if err := (PasswordPolicy{MinLength: 4}).RegisterValidators(v); err != nil {
+		t.Fatal(err)
+	}
+
+	type input struct {
+		Password string `validate:"password"`
+	}
+	if err := v.Struct(input{Password: "password"}); err != nil {
+		t.Errorf("Expected only the length to be checked, got %v", err)
+	}
+}
+
+func TestPasswordPolicy_ContainsPersonal(t *testing.T) {
+	p := DefaultPasswordPolicy()
+
+	if !p.ContainsPersonal("Jane.Doe#2024", "jane.doe@example.com") {
+		t.Error("Expected email local part to be rejected")
+	}
+	if !p.ContainsPersonal("SunnyCare2024!", "jane@example.com", "Sunny Care") {
+		t.Error("Expected organization name to be rejected")
+	}
+	if p.ContainsPersonal("Xy9!kLm#pQ", "jo@example.com", "AB") {
+		t.Error("Expected short parts to be ignored")
+	}
+}
+
+func TestIsCommonPassword(t *testing.T) {
+	if !IsCommonPassword("Password123") {
+		t.Error("Expected case-insensitive match")
+	}
+	if IsCommonPassword("#comment") || IsCommonPassword("") {
+		t.Error("Expected comments and blank lines to be skipped")
+	}
+}
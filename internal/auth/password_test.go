@@ -1,7 +1,11 @@
 package auth
 
 import (
+	"errors"
+	"strings"
 	"testing"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestHashPassword(t *testing.T) {
@@ -34,3 +38,140 @@ func TestCheckPasswordHash(t *testing.T) {
 		t.Error("CheckPasswordHash succeeded for wrong password")
 	}
 }
+
+func TestPasswordHasher_Algorithms(t *testing.T) {
+	argon := DefaultPasswordHasher()
+	bcryptHasher := DefaultPasswordHasher()
+	bcryptHasher.Algorithm = AlgBcrypt
+	bcryptHasher.BcryptCost = 10
+
+	for _, h := range []PasswordHasher{argon, bcryptHasher} {
+		t.Run(h.Algorithm, func(t *testing.T) {
+			if err := h.Validate(); err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+			hash, err := h.Hash("TestPass123!")
+			if err != nil {
+				t.Fatalf("Hash failed: %v", err)
+			}
+			if err := CheckPasswordHash("TestPass123!", hash); err != nil {
+				t.Errorf("Expected password to match, got %v", err)
+			}
+			if err := CheckPasswordHash("TestPass123?", hash); !errors.Is(err, ErrInvalidPassword) {
+				t.Errorf("Expected ErrInvalidPassword, got %v", err)
+			}
+			if h.NeedsRehash(hash) {
+				t.Error("Expected a fresh hash not to need a rehash")
+			}
+		})
+	}
+}
+
+func TestPasswordHasher_Format(t *testing.T) {
+	hash, _ := DefaultPasswordHasher().Hash("secret")
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Errorf("Unexpected hash format: %s", hash)
+	}
+
+	other, _ := DefaultPasswordHasher().Hash("secret")
+	if hash == other {
+		t.Error("Expected hashes to be salted")
+	}
+}
+
+func TestPasswordHasher_NeedsRehash(t *testing.T) {
+	current := DefaultPasswordHasher()
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+
+	stronger := current
+	stronger.Argon2.Memory = 64 * 1024
+	weakHash, _ := current.Hash("secret")
+
+	bcryptHasher := current
+	bcryptHasher.Algorithm = AlgBcrypt
+	bcryptHasher.BcryptCost = 10
+	bcryptHash, _ := bcryptHasher.Hash("secret")
+
+	tests := []struct {
+		name   string
+		hasher PasswordHasher
+		hash   string
+		want   bool
+	}{
+		{"legacy bcrypt to argon2id", current, string(legacy), true},
+		{"argon2id memory raised", stronger, weakHash, true},
+		{"argon2id unchanged", current, weakHash, false},
+		{"bcrypt cost raised", PasswordHasher{Algorithm: AlgBcrypt, BcryptCost: 12}, bcryptHash, true},
+		{"bcrypt unchanged", bcryptHasher, bcryptHash, false},
+		{"argon2id back to bcrypt", bcryptHasher, weakHash, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.hasher.NeedsRehash(tc.hash); got != tc.want {
+				t.Errorf("NeedsRehash = %t, want %t", got, tc.want)
+			}
+		})
+	}
+
+	// Legacy hashes keep verifying
+	if err := CheckPasswordHash("secret", string(legacy)); err != nil {
+		t.Errorf("Expected legacy bcrypt hash to verify, got %v", err)
+	}
+}
+
+func TestPasswordHasher_Validate(t *testing.T) {
+	invalid := []PasswordHasher{
+		{Algorithm: "md5"},
+		{Algorithm: AlgBcrypt, BcryptCost: 4},
+		{Algorithm: AlgBcrypt, BcryptCost: 20},
+		{Algorithm: AlgArgon2id, Argon2: Argon2Params{Memory: 1024, Iterations: 2, Parallelism: 1, SaltLen: 16, KeyLen: 32}},
+		{Algorithm: AlgArgon2id, Argon2: Argon2Params{Memory: 19456, Iterations: 50, Parallelism: 1, SaltLen: 16, KeyLen: 32}},
+	}
+	for _, h := range invalid {
+		if err := h.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", h)
+		}
+	}
+}
+
+func TestCheckPasswordHash_Malformed(t *testing.T) {
+	hashes := []string{
+		"",
+		"$argon2id$v=19$m=19456,t=2,p=1$c2FsdA",
+		"$argon2id$v=16$m=19456,t=2,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=99999999,t=2,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5", // Would allocate ~95 GiB
+	}
+	for _, hash := range hashes {
+		if err := CheckPasswordHash("secret", hash); !errors.Is(err, ErrInvalidPassword) {
+			t.Errorf("Expected %q to be rejected, got %v", hash, err)
+		}
+	}
+}
+
+// Login latency is dominated by the password check: run these when changing the default parameters.
+//
+//	go test ./internal/auth -run '^$' -bench Password -benchmem
+
+func BenchmarkPasswordHash_Argon2id(b *testing.B) {
+	h := DefaultPasswordHasher()
+	for i := 0; i < b.N; i++ {
+		_, _ = h.Hash("TestPass123!")
+	}
+}
+
+func BenchmarkPasswordCheck_Argon2id(b *testing.B) {
+	hash, _ := DefaultPasswordHasher().Hash("TestPass123!")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = CheckPasswordHash("TestPass123!", hash)
+	}
+}
+
+func BenchmarkPasswordCheck_Bcrypt(b *testing.B) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("TestPass123!"), DefaultPasswordHasher().BcryptCost)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = CheckPasswordHash("TestPass123!", string(hash))
+	}
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// Authentication methods (RFC 8176), recorded in the "amr" claim.
+const (
+	// AMRPassword is a password.
+	AMRPassword = "pwd"
+	// AMROTP is a one-time code: a TOTP or recovery code.
+	AMROTP = "otp"
+	// AMRMultiFactor is added when more than one factor was used.
+	AMRMultiFactor = "mfa"
+	// AMRFederated is a sign-in provider. It is not registered in RFC 8176.
+	AMRFederated = "fed"
+)
+
+// DefaultReauthMaxAge is how recent an authentication RequireRecentAuth accepts by default.
+const DefaultReauthMaxAge = 10 * time.Minute
+
+// CodeReauthenticationRequired is the error code of requests refused by RequireRecentAuth.
+// Clients should call POST /auth/reauthenticate and retry with the new token.
+const CodeReauthenticationRequired = "reauthentication_required"
+
+// SetAuthentication records in the claims that the user proved their credentials with amr at t.
+func (c *Claims) SetAuthentication(t time.Time, amr []string) {
+	c.AuthTime = jwt.NewNumericDate(t)
+	c.AMR = amr
+}
+
+// AuthenticatedWithin reports whether the user proved their credentials at most maxAge before now.
+func (c *Claims) AuthenticatedWithin(maxAge time.Duration, now time.Time) bool {
+	return c.AuthTime != nil && !now.After(c.AuthTime.Add(maxAge))
+}
+
+// WithAMR returns methods with method added (once), and "mfa" once it holds two different factors.
+func WithAMR(methods []string, method string) []string {
+	out := make([]string, 0, len(methods)+2)
+	seen := make(map[string]bool, len(methods)+2)
+	for _, m := range append(append([]string{}, methods...), method) {
+		if !seen[m] {
+			seen[m] = true
+			out = append(out, m)
+		}
+	}
+	if !seen[AMRMultiFactor] && seen[AMROTP] && (seen[AMRPassword] || seen[AMRFederated]) {
+		out = append(out, AMRMultiFactor)
+	}
+	return out
+}
+
+// RequireRecentAuth is a middleware for sensitive routes, requiring the user to have proven their
+// credentials within maxAge. Other requests get a 401 with CodeReauthenticationRequired and an
+// RFC 9470 step-up challenge. It must run after Authenticate.
+func RequireRecentAuth(maxAge time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !MustClaims(r.Context()).AuthenticatedWithin(maxAge, time.Now()) {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+					`Bearer realm="sal-api", error="insufficient_user_authentication", max_age=%d`, int(maxAge.Seconds()),
+				))
+				response.ErrorCode(w, http.StatusUnauthorized, CodeReauthenticationRequired, "Please confirm your identity to continue")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
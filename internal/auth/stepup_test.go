@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthenticatedWithin(t *testing.T) {
+	now := time.Now()
+
+	var never Claims
+	if never.AuthenticatedWithin(time.Hour, now) {
+		t.Error("Expected claims without auth_time to be stale")
+	}
+
+	var c Claims
+	c.SetAuthentication(now.Add(-5*time.Minute), []string{AMRPassword})
+	if !c.AuthenticatedWithin(10*time.Minute, now) {
+		t.Error("Expected a 5 minute old authentication to be within 10 minutes")
+	}
+	if c.AuthenticatedWithin(time.Minute, now) {
+		t.Error("Expected a 5 minute old authentication not to be within 1 minute")
+	}
+}
+
+func TestWithAMR(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods []string
+		method  string
+		want    []string
+	}{
+		{"First Method", nil, AMRPassword, []string{AMRPassword}},
+		{"Second Factor", []string{AMRPassword}, AMROTP, []string{AMRPassword, AMROTP, AMRMultiFactor}},
+		{"Federated Then OTP", []string{AMRFederated}, AMROTP, []string{AMRFederated, AMROTP, AMRMultiFactor}},
+		{"Repeated", []string{AMRPassword, AMROTP, AMRMultiFactor}, AMROTP, []string{AMRPassword, AMROTP, AMRMultiFactor}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := WithAMR(tc.methods, tc.method); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRequireRecentAuth(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mw := RequireRecentAuth(10 * time.Minute)
+
+	serve := func(authTime time.Time) *httptest.ResponseRecorder {
+		claims := &Claims{UserID: "user-1"}
+		claims.SetAuthentication(authTime, []string{AMRPassword})
+		req := httptest.NewRequest("DELETE", "/", nil)
+		rr := httptest.NewRecorder()
+		mw(next).ServeHTTP(rr, req.WithContext(WithClaims(req.Context(), claims)))
+		return rr
+	}
+
+	if rr := serve(time.Now().Add(-time.Minute)); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected recent authentication to pass, got %d", rr.Code)
+	}
+
+	rr := serve(time.Now().Add(-time.Hour))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401, got %d", rr.Code)
+	}
+	if h := rr.Header().Get("WWW-Authenticate"); !strings.Contains(h, `error="insufficient_user_authentication"`) || !strings.Contains(h, "max_age=600") {
+		t.Errorf("Unexpected challenge: %s", h)
+	}
+	var body struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data["code"] != CodeReauthenticationRequired {
+		t.Errorf("Expected code %s, got %v", CodeReauthenticationRequired, body.Data)
+	}
+}
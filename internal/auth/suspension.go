@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// CodeOrgSuspended is the error code of requests refused because the organization is suspended.
+const CodeOrgSuspended = "organization_suspended"
+
+// ErrOrgSuspended is returned when a token belongs to a suspended organization.
+var ErrOrgSuspended = errors.New("organization suspended")
+
+// SuspensionChecker reports whether an organization is suspended and, if so, whether it keeps read-only access.
+type SuspensionChecker interface {
+	OrgSuspension(ctx context.Context, orgID string) (suspended, readOnly bool, err error)
+}
+
+// DenySuspendedWrites is a middleware that refuses writes with 403 organization_suspended
+// when the organization is suspended in read-only mode (see Claims.ReadOnly). It must run after Authenticate.
+func DenySuspendedWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if MustClaims(r.Context()).ReadOnly && !readOnlyMethod(r.Method) {
+			response.ErrorCode(w, http.StatusForbidden, CodeOrgSuspended, "Organization is suspended: read-only access")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readOnlyMethod reports whether method does not change data.
+func readOnlyMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticate_SuspendedOrg(t *testing.T) {
+	v := NewVerifier(testKeys, fakeRevocations{}, fakeSuspensions{"suspended-org": false})
+	token, _ := SignAccessToken(Claims{UserID: "user-1", OrgID: "suspended-org", SessionID: "live-session"}, testKeys)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	Authenticate(v)(http.NotFoundHandler()).ServeHTTP(rr, req)
+
+	var body struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusForbidden || body.Data["code"] != CodeOrgSuspended {
+		t.Errorf("Expected 403 %s, got %d: %v", CodeOrgSuspended, rr.Code, body.Data)
+	}
+}
+
+func TestDenySuspendedWrites(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name     string
+		method   string
+		readOnly bool
+		want     int
+	}{
+		{"Read", "GET", true, http.StatusNoContent},
+		{"Write", "POST", true, http.StatusForbidden},
+		{"Active Organization", "DELETE", false, http.StatusNoContent},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/", nil)
+			req = req.WithContext(WithClaims(req.Context(), &Claims{UserID: "user-1", OrgID: "org-1", ReadOnly: tc.readOnly}))
+			rr := httptest.NewRecorder()
+			DenySuspendedWrites(next).ServeHTTP(rr, req)
+			if rr.Code != tc.want {
+				t.Errorf("Expected status %d, got %d", tc.want, rr.Code)
+			}
+		})
+	}
+}
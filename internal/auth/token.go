@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -10,35 +11,142 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// AccessTokenDuration is the lifespan of an access token (15 minutes).
+// AccessTokenDuration is the default lifespan of an access token (15 minutes).
 const AccessTokenDuration = 15 * time.Minute
 
+// RefreshTokenDuration is the default lifespan of a refresh token (7 days).
+// Every rotation issues a new token with a fresh lifespan.
+const RefreshTokenDuration = 7 * 24 * time.Hour
+
 // RefreshTokenLen is the byte length of the refresh token (32 bytes = 64 hex chars).
 const RefreshTokenLen = 32
 
+// ChallengeTokenDuration is the lifespan of a challenge token.
+const ChallengeTokenDuration = 5 * time.Minute
+
+// EmailVerificationTokenDuration is the lifespan of an email verification link.
+const EmailVerificationTokenDuration = 24 * time.Hour
+
+// PasswordResetTokenDuration is the lifespan of a password reset link.
+const PasswordResetTokenDuration = time.Hour
+
+// InvitationTokenDuration is the lifespan of a staff invitation link.
+const InvitationTokenDuration = 7 * 24 * time.Hour
+
+// Token purposes. Access tokens have no purpose; challenge tokens carry one of these
+// and are only accepted by the endpoint completing that step.
+const (
+	// PurposeMFA marks a token that may only be exchanged for tokens with a second factor.
+	PurposeMFA = "mfa"
+	// PurposeMFAEnroll marks a token that may only be used to enrol in two-factor authentication.
+	PurposeMFAEnroll = "mfa_enroll"
+	// PurposeVerifyEmail marks a token proving control of the email address in its "email" claim.
+	PurposeVerifyEmail = "verify_email"
+)
+
+// ErrTokenPurpose is returned when a token is presented where a different kind of token is expected,
+// e.g. a challenge token used as an access token.
+var ErrTokenPurpose = errors.New("token purpose mismatch")
+
+// Issuer is the value of the "iss" claim in every token issued by this API.
+const Issuer = "sal-api"
+
 // Claims represents the JWT payload.
 type Claims struct {
-	UserID string `json:"sub"`
-	OrgID  string `json:"org_id,omitempty"`
-	Role   string `json:"role,omitempty"`
+	UserID    string `json:"sub"`
+	OrgID     string `json:"org_id,omitempty"`
+	Role      string `json:"role,omitempty"`
+	SessionID string `json:"sid,omitempty"`     // Session the token was issued for, used for revocation
+	Purpose   string `json:"purpose,omitempty"` // Set on challenge tokens only
+	Email     string `json:"email,omitempty"`   // Set on email verification tokens only
+	Actor     *Actor `json:"act,omitempty"`     // Set on impersonation tokens only
+
+	// When and how the user last proved their credentials, see RequireRecentAuth
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
+
+	// Set when the bearer token is an API key instead of a JWT; never signed
+	APIKeyID     string   `json:"-"`
+	Capabilities []string `json:"-"`
+
+	// Set by Verifier when the organization is suspended in read-only mode; never signed
+	ReadOnly bool `json:"-"`
+
 	jwt.RegisteredClaims
 }
 
+// Actor is the party acting on behalf of the subject of a token (RFC 8693 "act" claim):
+// a platform admin impersonating the user.
+type Actor struct {
+	UserID string `json:"sub"`
+}
+
+// Impersonated reports whether the token was issued to someone acting as its subject.
+func (c *Claims) Impersonated() bool {
+	return c.Actor != nil
+}
+
 // NewAccessToken creates a signed JWT for the given user context.
-func NewAccessToken(userID, orgID, role, secret string) (string, error) {
-	claims := Claims{
+func NewAccessToken(userID, orgID, role string, keys *KeyRing) (string, error) {
+	return SignAccessToken(Claims{
 		UserID: userID,
 		OrgID:  orgID,
 		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenDuration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "sal-api",
-		},
+	}, keys)
+}
+
+// SignAccessToken signs the given claims as an access token valid for AccessTokenDuration.
+// The registered claims (issuer, issue time, expiry and a unique "jti") are filled in here.
+func SignAccessToken(claims Claims, keys *KeyRing) (string, error) {
+	return SignAccessTokenFor(claims, AccessTokenDuration, keys)
+}
+
+// SignAccessTokenFor signs the given claims as an access token with a custom lifespan.
+func SignAccessTokenFor(claims Claims, ttl time.Duration, keys *KeyRing) (string, error) {
+	claims.Purpose = ""
+	claims.Actor = nil
+	return signToken(claims, ttl, keys)
+}
+
+// SignImpersonationToken signs an access token for claims.UserID, used by actorID, valid for ttl.
+// It is the only way to issue a token with an "act" claim.
+func SignImpersonationToken(claims Claims, actorID string, ttl time.Duration, keys *KeyRing) (string, error) {
+	claims.Purpose = ""
+	claims.Actor = &Actor{UserID: actorID}
+	return signToken(claims, ttl, keys)
+}
+
+// SignChallengeToken signs a short-lived token for an intermediate login step such as PurposeMFA.
+// It cannot be used as an access token.
+func SignChallengeToken(claims Claims, purpose string, keys *KeyRing) (string, error) {
+	return SignPurposeToken(claims, purpose, ChallengeTokenDuration, keys)
+}
+
+// SignPurposeToken signs a token for purpose with a custom lifespan.
+// Like challenge tokens, it cannot be used as an access token.
+func SignPurposeToken(claims Claims, purpose string, ttl time.Duration, keys *KeyRing) (string, error) {
+	claims.Purpose = purpose
+	claims.SessionID = ""
+	claims.Actor = nil
+	return signToken(claims, ttl, keys)
+}
+
+// signToken fills in the registered claims and signs the token with the active key.
+func signToken(claims Claims, ttl time.Duration, keys *KeyRing) (string, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        hex.EncodeToString(jti),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Issuer:    Issuer,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return keys.sign(claims)
 }
 
 // NewRefreshToken generates a secure random hex string.
@@ -51,14 +159,58 @@ func NewRefreshToken() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
+// NewPasswordResetToken generates a password reset token.
+// It has the same format as refresh tokens and is hashed with HashRefreshToken before storage.
+func NewPasswordResetToken() (string, error) {
+	return NewRefreshToken()
+}
+
+// NewInvitationToken generates a staff invitation token.
+// It has the same format as refresh tokens and is hashed with HashRefreshToken before storage.
+func NewInvitationToken() (string, error) {
+	return NewRefreshToken()
+}
+
+// HashRefreshToken returns the SHA-256 hex digest of a refresh token.
+// Only the digest is persisted, so a database leak does not expose usable tokens.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // ParseAccessToken validates the token string and returns the claims.
-func ParseAccessToken(tokenString, secret string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
+// The signature (with the key named by the "kid" header), expiry and issuer are all checked,
+// and challenge tokens are rejected.
+func ParseAccessToken(tokenString string, keys *KeyRing) (*Claims, error) {
+	claims, err := parseToken(tokenString, keys)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, ErrTokenPurpose
+	}
+	return claims, nil
+}
+
+// ParseChallengeToken validates a challenge or purpose token issued for purpose and returns the claims.
+func ParseChallengeToken(tokenString, purpose string, keys *KeyRing) (*Claims, error) {
+	claims, err := parseToken(tokenString, keys)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, ErrTokenPurpose
+	}
+	return claims, nil
+}
+
+// parseToken checks the signature, expiry and issuer of a token.
+func parseToken(tokenString string, keys *KeyRing) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.keyFunc,
+		jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgEdDSA}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithExpirationRequired(),
+	)
 
 	if err != nil {
 		return nil, err
@@ -68,5 +220,5 @@ func ParseAccessToken(tokenString, secret string) (*Claims, error) {
 		return claims, nil
 	}
 
-	return nil, errors.New("invalid token")
+	return nil, jwt.ErrTokenInvalidClaims
 }
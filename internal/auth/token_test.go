@@ -1,11 +1,13 @@
 package auth
 
 import (
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestNewAccessToken(t *testing.T) {
-	token, err := NewAccessToken("user-1", "org-1", "admin", "secret")
+	token, err := NewAccessToken("user-1", "org-1", "admin", testKeys)
 	if err != nil {
 		t.Fatalf("NewAccessToken failed: %v", err)
 	}
@@ -15,7 +17,7 @@ func TestNewAccessToken(t *testing.T) {
 	}
 
 	// Parse it back
-	claims, err := ParseAccessToken(token, "secret")
+	claims, err := ParseAccessToken(token, testKeys)
 	if err != nil {
 		t.Fatalf("ParseAccessToken failed: %v", err)
 	}
@@ -29,8 +31,8 @@ func TestNewAccessToken(t *testing.T) {
 }
 
 func TestParseAccessToken_InvalidSignature(t *testing.T) {
-	token, _ := NewAccessToken("user-1", "org-1", "admin", "secret")
-	_, err := ParseAccessToken(token, "wrong-secret")
+	token, _ := NewAccessToken("user-1", "org-1", "admin", testKeys)
+	_, err := ParseAccessToken(token, NewHMACKeyRing("wrong-secret"))
 	if err == nil {
 		t.Error("Expected error for invalid signature, got nil")
 	}
@@ -47,14 +49,80 @@ func TestNewRefreshToken(t *testing.T) {
 	}
 }
 
+func TestHashRefreshToken(t *testing.T) {
+	token, _ := NewRefreshToken()
+
+	hash := HashRefreshToken(token)
+	if len(hash) != 64 {
+		t.Errorf("Expected length 64, got %d", len(hash))
+	}
+	if hash == token {
+		t.Error("HashRefreshToken returned the plain token")
+	}
+	if HashRefreshToken(token) != hash {
+		t.Error("HashRefreshToken is not deterministic")
+	}
+}
+
 func TestParseAccessToken_Expired(t *testing.T) {
 	// We can't easily mock time in the current token implementation without refactoring.
 	// But we can test malformed tokens.
 }
 
 func TestParseAccessToken_Malformed(t *testing.T) {
-	_, err := ParseAccessToken("invalid-token", "secret")
+	_, err := ParseAccessToken("invalid-token", testKeys)
 	if err == nil {
 		t.Error("Expected error for malformed token, got nil")
 	}
 }
+
+func TestChallengeToken(t *testing.T) {
+	token, err := SignChallengeToken(Claims{UserID: "user-1", OrgID: "org-1"}, PurposeMFA, testKeys)
+	if err != nil {
+		t.Fatalf("SignChallengeToken failed: %v", err)
+	}
+
+	if _, err := ParseAccessToken(token, testKeys); !errors.Is(err, ErrTokenPurpose) {
+		t.Errorf("Expected challenge token to be rejected as access token, got %v", err)
+	}
+	if _, err := ParseChallengeToken(token, PurposeMFAEnroll, testKeys); !errors.Is(err, ErrTokenPurpose) {
+		t.Errorf("Expected purpose mismatch, got %v", err)
+	}
+
+	claims, err := ParseChallengeToken(token, PurposeMFA, testKeys)
+	if err != nil {
+		t.Fatalf("ParseChallengeToken failed: %v", err)
+	}
+	if claims.UserID != "user-1" || claims.OrgID != "org-1" {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	access, _ := NewAccessToken("user-1", "org-1", "admin", testKeys)
+	if _, err := ParseChallengeToken(access, PurposeMFA, testKeys); !errors.Is(err, ErrTokenPurpose) {
+		t.Errorf("Expected access token to be rejected as challenge token, got %v", err)
+	}
+}
+
+func TestImpersonationToken(t *testing.T) {
+	token, err := SignImpersonationToken(Claims{UserID: "user-1", OrgID: "org-1", SessionID: "s-1"}, "admin-1", 30*time.Minute, testKeys)
+	if err != nil {
+		t.Fatalf("SignImpersonationToken failed: %v", err)
+	}
+
+	claims, err := ParseAccessToken(token, testKeys)
+	if err != nil {
+		t.Fatalf("ParseAccessToken failed: %v", err)
+	}
+	if !claims.Impersonated() || claims.Actor.UserID != "admin-1" || claims.UserID != "user-1" {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+	if ttl := time.Until(claims.ExpiresAt.Time); ttl < 29*time.Minute || ttl > 30*time.Minute {
+		t.Errorf("Expected a 30m token, got %v", ttl)
+	}
+
+	// Re-signing the claims as a plain access token drops the actor
+	plain, _ := SignAccessToken(*claims, testKeys)
+	if claims, _ := ParseAccessToken(plain, testKeys); claims.Impersonated() {
+		t.Error("Expected SignAccessToken to drop the act claim")
+	}
+}
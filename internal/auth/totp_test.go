@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the RFC 6238 SHA-1 test key "12345678901234567890" in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tc := range tests {
+		got, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(tc.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("At %d: expected %s, got %s", tc.unix, tc.want, got)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	code, _ := TOTPCode(secret, TOTPStep(now))
+
+	if step, ok := ValidateTOTP(secret, code, now); !ok || step != TOTPStep(now) {
+		t.Errorf("Expected current code to validate at step %d, got %d/%v", TOTPStep(now), step, ok)
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(TOTPPeriod)); !ok {
+		t.Error("Expected code from the previous period to be accepted")
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(3*TOTPPeriod)); ok {
+		t.Error("Expected stale code to be rejected")
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now); ok {
+		t.Error("Expected short code to be rejected")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Sal", "jane@example.com", rfcSecret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Sal:jane@example.com?") {
+		t.Errorf("Unexpected URI prefix: %s", uri)
+	}
+	if !strings.Contains(uri, "secret="+rfcSecret) {
+		t.Errorf("Expected secret in URI: %s", uri)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := NewRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != RecoveryCodeCount {
+		t.Fatalf("Expected %d codes, got %d", RecoveryCodeCount, len(codes))
+	}
+
+	seen := map[string]bool{}
+	for _, c := range codes {
+		if len(c) != 11 || c[5] != '-' {
+			t.Errorf("Unexpected code format: %q", c)
+		}
+		seen[c] = true
+	}
+	if len(seen) != len(codes) {
+		t.Error("Expected unique codes")
+	}
+
+	if HashRecoveryCode(codes[0]) != HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[0], "-", " "))) {
+		t.Error("Expected hashing to ignore case, spaces and hyphens")
+	}
+}
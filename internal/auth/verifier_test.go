@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeRevocations is an in-memory RevocationChecker.
+type fakeRevocations map[string]bool
+
+func (f fakeRevocations) IsSessionRevoked(_ context.Context, sessionID string) (bool, error) {
+	return f[sessionID], nil
+}
+
+// fakeSuspensions is an in-memory SuspensionChecker: suspended organizations map to whether they are read-only.
+type fakeSuspensions map[string]bool
+
+func (f fakeSuspensions) OrgSuspension(_ context.Context, orgID string) (bool, bool, error) {
+	readOnly, suspended := f[orgID]
+	return suspended, readOnly, nil
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	v := NewVerifier(testKeys, fakeRevocations{"revoked-session": true}, fakeSuspensions{})
+
+	token, _ := SignAccessToken(Claims{UserID: "user-1", SessionID: "live-session"}, testKeys)
+	claims, err := v.Verify(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if claims.SessionID != "live-session" {
+		t.Errorf("Expected SessionID live-session, got %s", claims.SessionID)
+	}
+	if claims.ID == "" {
+		t.Error("Expected a jti to be set")
+	}
+}
+
+func TestVerifier_Revoked(t *testing.T) {
+	v := NewVerifier(testKeys, fakeRevocations{"revoked-session": true}, fakeSuspensions{})
+
+	token, _ := SignAccessToken(Claims{UserID: "user-1", SessionID: "revoked-session"}, testKeys)
+	if _, err := v.Verify(context.Background(), token); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected ErrTokenRevoked, got %v", err)
+	}
+}
+
+func TestVerifier_MissingSession(t *testing.T) {
+	v := NewVerifier(testKeys, fakeRevocations{}, fakeSuspensions{})
+
+	token, _ := NewAccessToken("user-1", "org-1", "admin", testKeys)
+	if _, err := v.Verify(context.Background(), token); !errors.Is(err, ErrMissingSession) {
+		t.Errorf("Expected ErrMissingSession, got %v", err)
+	}
+}
+
+func TestVerifier_SuspendedOrg(t *testing.T) {
+	v := NewVerifier(testKeys, fakeRevocations{}, fakeSuspensions{"suspended-org": false, "read-only-org": true})
+
+	token, _ := SignAccessToken(Claims{UserID: "user-1", OrgID: "suspended-org", SessionID: "live-session"}, testKeys)
+	if _, err := v.Verify(context.Background(), token); !errors.Is(err, ErrOrgSuspended) {
+		t.Errorf("Expected ErrOrgSuspended, got %v", err)
+	}
+
+	token, _ = SignAccessToken(Claims{UserID: "user-1", OrgID: "read-only-org", SessionID: "live-session"}, testKeys)
+	claims, err := v.Verify(context.Background(), token)
+	if err != nil || !claims.ReadOnly {
+		t.Errorf("Expected read-only claims, got %+v, %v", claims, err)
+	}
+
+	token, _ = SignAccessToken(Claims{UserID: "user-1", OrgID: "org-1", SessionID: "live-session"}, testKeys)
+	if claims, err := v.Verify(context.Background(), token); err != nil || claims.ReadOnly {
+		t.Errorf("Expected active organization to be unrestricted, got %+v, %v", claims, err)
+	}
+}
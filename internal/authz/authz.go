@@ -0,0 +1,204 @@
+// Package authz provides authorization middleware based on staff roles and permissions.
+//
+// It runs after auth.Authenticate: the token identifies the user and organization,
+// and the staff row for that pair decides what the caller may do.
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// DefaultCacheTTL is how long a loaded staff row is reused before it is read again.
+// Keep it short: permission changes take up to this long to apply.
+const DefaultCacheTTL = 30 * time.Second
+
+// maxCacheEntries bounds the cache; expired entries are pruned once it is reached.
+const maxCacheEntries = 10000
+
+// RoleAdmin is the staff role that bypasses permission checks.
+const RoleAdmin = "admin"
+
+// MembershipLoader loads the active staff row of a user in an organization.
+// It is satisfied by *repository.StaffRepository.
+type MembershipLoader interface {
+	GetStaffByUserAndOrg(ctx context.Context, userID, orgID string) (*repository.Staff, error)
+}
+
+// contextKey is an unexported type for context keys defined in this package.
+type contextKey int
+
+// staffKey is the context key holding the caller's *repository.Staff.
+const staffKey contextKey = iota
+
+// cachedStaff is a cache entry.
+type cachedStaff struct {
+	staff   *repository.Staff
+	expires time.Time
+}
+
+// Authorizer checks the caller's staff membership against required roles and capabilities.
+type Authorizer struct {
+	loader       MembershipLoader
+	ttl          time.Duration
+	reauthMaxAge time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedStaff
+}
+
+// NewAuthorizer creates an Authorizer that caches staff rows for ttl.
+// Sensitive capabilities require an authentication within reauthMaxAge; 0 disables the check.
+func NewAuthorizer(loader MembershipLoader, ttl, reauthMaxAge time.Duration) *Authorizer {
+	return &Authorizer{
+		loader:       loader,
+		ttl:          ttl,
+		reauthMaxAge: reauthMaxAge,
+		cache:        make(map[string]cachedStaff),
+	}
+}
+
+// RequirePermission is a middleware that only lets callers holding capability through
+// (e.g. "notes.update_any"). Admins are always allowed, except to destructive capabilities while impersonated.
+// API keys are allowed the capabilities they were granted.
+// Sensitive capabilities also require a recent authentication (see auth.RequireRecentAuth).
+// It panics on unknown capabilities so typos fail at startup rather than deny silently.
+func (a *Authorizer) RequirePermission(capability string) func(http.Handler) http.Handler {
+	if !repository.IsCapability(capability) {
+		panic(fmt.Sprintf("authz: unknown capability %q", capability))
+	}
+
+	allow := a.require(func(s *repository.Staff) bool {
+		return s.Role == RoleAdmin || s.Permissions.Allows(capability)
+	}, func(c *auth.Claims) bool {
+		return c.HasCapability(capability)
+	})
+	if repository.IsSensitive(capability) && a.reauthMaxAge > 0 {
+		// Checked after the permission, so callers are only asked to reauthenticate when it can help
+		recent, permitted := auth.RequireRecentAuth(a.reauthMaxAge), allow
+		allow = func(next http.Handler) http.Handler {
+			return permitted(recent(next))
+		}
+	}
+	if !repository.IsDestructive(capability) {
+		return allow
+	}
+
+	// Support staff acting as a user may look, but not destroy anything
+	return func(next http.Handler) http.Handler {
+		return auth.DenyImpersonation(allow(next))
+	}
+}
+
+// RequireRole is a middleware that only lets callers with the given staff role through.
+// API keys have no role and are always refused.
+func (a *Authorizer) RequireRole(role string) func(http.Handler) http.Handler {
+	return a.require(func(s *repository.Staff) bool {
+		return s.Role == role
+	}, nil)
+}
+
+// RequireMember is a middleware that lets any active member of the token's organization through,
+// and the organization's API keys.
+func (a *Authorizer) RequireMember() func(http.Handler) http.Handler {
+	return a.require(func(*repository.Staff) bool {
+		return true
+	}, func(*auth.Claims) bool {
+		return true
+	})
+}
+
+// require builds a middleware that loads the caller's membership and applies allow.
+// API keys have no membership: allowKey decides for them, and a nil allowKey refuses them.
+func (a *Authorizer) require(allow func(s *repository.Staff) bool, allowKey func(c *auth.Claims) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := auth.MustClaims(r.Context())
+			if claims.OrgID == "" {
+				response.Error(w, http.StatusForbidden, "No organization selected")
+				return
+			}
+
+			if claims.IsAPIKey() {
+				if allowKey == nil || !allowKey(claims) {
+					response.Error(w, http.StatusForbidden, "Insufficient permissions")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			staff, err := a.Membership(r.Context(), claims.UserID, claims.OrgID)
+			if err != nil {
+				if errors.Is(err, repository.ErrStaffNotFound) {
+					response.Error(w, http.StatusForbidden, "Not an active member of this organization")
+					return
+				}
+				response.Error(w, http.StatusInternalServerError, "Failed to load permissions")
+				return
+			}
+
+			if !allow(staff) {
+				response.Error(w, http.StatusForbidden, "Insufficient permissions")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), staffKey, staff)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Membership returns the caller's active staff row, using the cache when fresh.
+func (a *Authorizer) Membership(ctx context.Context, userID, orgID string) (*repository.Staff, error) {
+	key := userID + "/" + orgID
+	now := time.Now()
+
+	a.mu.Lock()
+	entry, ok := a.cache[key]
+	a.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.staff, nil
+	}
+
+	staff, err := a.loader.GetStaffByUserAndOrg(ctx, userID, orgID)
+	if err != nil {
+		a.Invalidate(userID, orgID)
+		return nil, err
+	}
+
+	a.mu.Lock()
+	if len(a.cache) >= maxCacheEntries {
+		for k, e := range a.cache {
+			if now.After(e.expires) {
+				delete(a.cache, k)
+			}
+		}
+	}
+	a.cache[key] = cachedStaff{staff: staff, expires: now.Add(a.ttl)}
+	a.mu.Unlock()
+
+	return staff, nil
+}
+
+// Invalidate drops the cached membership of a user in an organization.
+// Call it after changing a staff row so the change applies immediately on this instance.
+func (a *Authorizer) Invalidate(userID, orgID string) {
+	a.mu.Lock()
+	delete(a.cache, userID+"/"+orgID)
+	a.mu.Unlock()
+}
+
+// StaffFromContext returns the staff row loaded by RequirePermission or RequireRole, if any.
+func StaffFromContext(ctx context.Context) (*repository.Staff, bool) {
+	staff, ok := ctx.Value(staffKey).(*repository.Staff)
+	return staff, ok && staff != nil
+}
@@ -0,0 +1,236 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/repository"
+)
+
+// fakeLoader serves staff rows from memory and counts lookups.
+type fakeLoader struct {
+	staff map[string]*repository.Staff
+	calls int
+}
+
+func (f *fakeLoader) GetStaffByUserAndOrg(_ context.Context, userID, orgID string) (*repository.Staff, error) {
+	f.calls++
+	s, ok := f.staff[userID+"/"+orgID]
+	if !ok {
+		return nil, repository.ErrStaffNotFound
+	}
+	return s, nil
+}
+
+func newFakeLoader() *fakeLoader {
+	nurse := &repository.Staff{Role: "staff", Permissions: repository.DefaultPermissions()}
+	admin := &repository.Staff{Role: RoleAdmin}
+	return &fakeLoader{staff: map[string]*repository.Staff{
+		"nurse/org-1": nurse,
+		"admin/org-1": admin,
+	}}
+}
+
+// serve runs a request as userID in org-1 through the middleware.
+func serve(mw func(http.Handler) http.Handler, userID string) int {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := StaffFromContext(r.Context()); !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UserID: userID, OrgID: "org-1"}))
+	rr := httptest.NewRecorder()
+	mw(next).ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestRequirePermission(t *testing.T) {
+	a := NewAuthorizer(newFakeLoader(), time.Minute, 0)
+
+	tests := []struct {
+		name       string
+		user       string
+		capability string
+		want       int
+	}{
+		{"Granted", "nurse", repository.CapNotesUpdateOwn, http.StatusNoContent},
+		{"Denied", "nurse", repository.CapNotesUpdateAny, http.StatusForbidden},
+		{"Admin Bypass", "admin", repository.CapNotesUpdateAny, http.StatusNoContent},
+		{"Not A Member", "stranger", repository.CapNotesRead, http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := serve(a.RequirePermission(tc.capability), tc.user); got != tc.want {
+				t.Errorf("Expected status %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRequirePermission_UnknownCapabilityPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for unknown capability")
+		}
+	}()
+	NewAuthorizer(newFakeLoader(), time.Minute, 0).RequirePermission("notes.teleport")
+}
+
+func TestRequireRole(t *testing.T) {
+	a := NewAuthorizer(newFakeLoader(), time.Minute, 0)
+
+	if got := serve(a.RequireRole(RoleAdmin), "admin"); got != http.StatusNoContent {
+		t.Errorf("Expected admin to pass, got %d", got)
+	}
+	if got := serve(a.RequireRole(RoleAdmin), "nurse"); got != http.StatusForbidden {
+		t.Errorf("Expected staff to be rejected, got %d", got)
+	}
+}
+
+func TestRequireMember(t *testing.T) {
+	a := NewAuthorizer(newFakeLoader(), time.Minute, 0)
+
+	if got := serve(a.RequireMember(), "nurse"); got != http.StatusNoContent {
+		t.Errorf("Expected members to pass, got %d", got)
+	}
+	if got := serve(a.RequireMember(), "stranger"); got != http.StatusForbidden {
+		t.Errorf("Expected strangers to be rejected, got %d", got)
+	}
+}
+
+func TestMembership_Cache(t *testing.T) {
+	loader := newFakeLoader()
+	a := NewAuthorizer(loader, time.Minute, 0)
+
+	for i := 0; i < 3; i++ {
+		if _, err := a.Membership(context.Background(), "nurse", "org-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if loader.calls != 1 {
+		t.Errorf("Expected 1 lookup, got %d", loader.calls)
+	}
+
+	a.Invalidate("nurse", "org-1")
+	_, _ = a.Membership(context.Background(), "nurse", "org-1")
+	if loader.calls != 2 {
+		t.Errorf("Expected a fresh lookup after Invalidate, got %d calls", loader.calls)
+	}
+}
+
+// fakeVerification blocks the listed users.
+type fakeVerification map[string]bool
+
+func (f fakeVerification) BlocksUnverifiedUser(_ context.Context, _, userID string) (bool, error) {
+	return f[userID], nil
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	mw := RequireVerifiedEmail(fakeVerification{"unverified": true})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for user, want := range map[string]int{"verified": http.StatusNoContent, "unverified": http.StatusForbidden} {
+		req := httptest.NewRequest("POST", "/", nil)
+		req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UserID: user, OrgID: "org-1"}))
+		rr := httptest.NewRecorder()
+		mw(next).ServeHTTP(rr, req)
+
+		if rr.Code != want {
+			t.Errorf("%s: expected %d, got %d", user, want, rr.Code)
+		}
+	}
+}
+
+func TestRequirePermission_Impersonated(t *testing.T) {
+	a := NewAuthorizer(newFakeLoader(), time.Minute, 0)
+
+	serveAs := func(capability string) int {
+		next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		req := httptest.NewRequest("DELETE", "/", nil)
+		claims := &auth.Claims{UserID: "admin", OrgID: "org-1", Actor: &auth.Actor{UserID: "support"}}
+		rr := httptest.NewRecorder()
+		a.RequirePermission(capability)(next).ServeHTTP(rr, req.WithContext(auth.WithClaims(req.Context(), claims)))
+		return rr.Code
+	}
+
+	// Even an admin's permissions don't extend to destroying data
+	if got := serveAs(repository.CapNotesDelete); got != http.StatusForbidden {
+		t.Errorf("Expected destructive capability to be denied, got %d", got)
+	}
+	if got := serveAs(repository.CapNotesUpdateAny); got != http.StatusNoContent {
+		t.Errorf("Expected other capabilities to be allowed, got %d", got)
+	}
+}
+
+func TestRequirePermission_RecentAuth(t *testing.T) {
+	a := NewAuthorizer(newFakeLoader(), time.Minute, 10*time.Minute)
+
+	serveAt := func(user, capability string, authTime time.Time) int {
+		next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		claims := &auth.Claims{UserID: user, OrgID: "org-1"}
+		claims.SetAuthentication(authTime, []string{auth.AMRPassword})
+		req := httptest.NewRequest("DELETE", "/", nil)
+		rr := httptest.NewRecorder()
+		a.RequirePermission(capability)(next).ServeHTTP(rr, req.WithContext(auth.WithClaims(req.Context(), claims)))
+		return rr.Code
+	}
+
+	stale := time.Now().Add(-time.Hour)
+	if got := serveAt("admin", repository.CapNotesDelete, time.Now()); got != http.StatusNoContent {
+		t.Errorf("Expected recent authentication to be allowed, got %d", got)
+	}
+	if got := serveAt("admin", repository.CapNotesUpdateAny, stale); got != http.StatusUnauthorized {
+		t.Errorf("Expected stale authentication to be refused, got %d", got)
+	}
+	if got := serveAt("admin", repository.CapNotesRead, stale); got != http.StatusNoContent {
+		t.Errorf("Expected other capabilities to ignore the authentication time, got %d", got)
+	}
+	// Without the permission, reauthenticating would not help
+	if got := serveAt("nurse", repository.CapNotesDelete, stale); got != http.StatusForbidden {
+		t.Errorf("Expected 403 without the permission, got %d", got)
+	}
+}
+
+func TestRequirePermission_APIKey(t *testing.T) {
+	loader := newFakeLoader()
+	a := NewAuthorizer(loader, time.Minute, 0)
+
+	serveKey := func(mw func(http.Handler) http.Handler) int {
+		next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		claims := &auth.Claims{OrgID: "org-1", APIKeyID: "key-1", Capabilities: []string{repository.CapNotesCreate}}
+		req := httptest.NewRequest("POST", "/", nil)
+		rr := httptest.NewRecorder()
+		mw(next).ServeHTTP(rr, req.WithContext(auth.WithClaims(req.Context(), claims)))
+		return rr.Code
+	}
+
+	if got := serveKey(a.RequirePermission(repository.CapNotesCreate)); got != http.StatusNoContent {
+		t.Errorf("Expected granted capability to be allowed, got %d", got)
+	}
+	if got := serveKey(a.RequirePermission(repository.CapNotesRead)); got != http.StatusForbidden {
+		t.Errorf("Expected other capabilities to be denied, got %d", got)
+	}
+	if got := serveKey(a.RequireRole(RoleAdmin)); got != http.StatusForbidden {
+		t.Errorf("Expected roles to be denied, got %d", got)
+	}
+	if loader.calls != 0 {
+		t.Errorf("Expected no membership lookups for API keys, got %d", loader.calls)
+	}
+}
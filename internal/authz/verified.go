@@ -0,0 +1,47 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// VerificationChecker decides whether an unverified email blocks a user in an organization.
+// It is satisfied by *repository.OrganizationRepository.
+type VerificationChecker interface {
+	BlocksUnverifiedUser(ctx context.Context, orgID, userID string) (bool, error)
+}
+
+// RequireVerifiedEmail is a middleware for note creation routes. It rejects callers whose
+// email is unverified when their organization enables settings.require_verified_email.
+// API keys have no email and pass.
+func RequireVerifiedEmail(checker VerificationChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := auth.MustClaims(r.Context())
+			if claims.OrgID == "" {
+				response.Error(w, http.StatusForbidden, "No organization selected")
+				return
+			}
+
+			if claims.IsAPIKey() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			blocked, err := checker.BlocksUnverifiedUser(r.Context(), claims.OrgID, claims.UserID)
+			if err != nil {
+				response.Error(w, http.StatusInternalServerError, "Failed to check email verification")
+				return
+			}
+			if blocked {
+				response.Error(w, http.StatusForbidden, "Email address must be verified")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
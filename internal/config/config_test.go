@@ -2,34 +2,186 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
 	// 1. Test Default
 	_ = os.Unsetenv("JWT_SECRET")
 	_ = os.Unsetenv("DATABASE_URL")
-	cfg := Load()
+	_ = os.Unsetenv("LOCKOUT_THRESHOLD")
+	_ = os.Unsetenv("LOCKOUT_BASE_DURATION")
+	_ = os.Unsetenv("MAIL_DRIVER")
+	_ = os.Unsetenv("PASSWORD_REQUIRE_SPECIAL")
+	_ = os.Unsetenv("GOOGLE_CLIENT_ID")
+	_ = os.Unsetenv("CORS_ALLOWED_ORIGINS")
+	_ = os.Unsetenv("ENV")
+	_ = os.Unsetenv("PORT")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected the defaults to be valid, got %v", err)
+	}
 
 	if cfg.JWTSecret != "super-secret-dev-key-change-me" {
 		t.Error("Expected default JWT secret")
 	}
+	if cfg.LockoutThreshold != 5 || cfg.LockoutBaseDuration != time.Minute {
+		t.Errorf("Expected default lockout policy, got %d/%s", cfg.LockoutThreshold, cfg.LockoutBaseDuration)
+	}
+	if cfg.PasswordMinLength != 8 || !cfg.PasswordRequireSpecial {
+		t.Errorf("Expected default password policy, got min %d, special %t", cfg.PasswordMinLength, cfg.PasswordRequireSpecial)
+	}
+	if cfg.MailDriver != "file" {
+		t.Errorf("Expected default mail driver file, got %s", cfg.MailDriver)
+	}
+	if cfg.PasswordHashAlgorithm != "argon2id" || cfg.Argon2MemoryKiB != 19*1024 || cfg.BcryptCost != 12 {
+		t.Errorf("Expected default password hashing, got %s/%d/%d", cfg.PasswordHashAlgorithm, cfg.Argon2MemoryKiB, cfg.BcryptCost)
+	}
+	if cfg.ImpersonationDuration != 30*time.Minute {
+		t.Errorf("Expected default impersonation duration 30m, got %s", cfg.ImpersonationDuration)
+	}
+	if len(cfg.CORSAllowedOrigins) != 1 || cfg.CORSAllowedOrigins[0] != cfg.AppURL {
+		t.Errorf("Expected CORS origins to default to the app URL, got %v", cfg.CORSAllowedOrigins)
+	}
+	if !cfg.IsDevelopment() {
+		t.Error("Expected development by default")
+	}
+	if cfg.Port != 8000 || cfg.DBMaxConns != 25 || cfg.AccessTokenTTL != 15*time.Minute || cfg.RefreshTokenTTL != 7*24*time.Hour {
+		t.Errorf("Expected default port, pool and token lifetimes, got %d/%d/%s/%s", cfg.Port, cfg.DBMaxConns, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	}
+	if cfg.ReauthMaxAge != 10*time.Minute {
+		t.Errorf("Expected default reauthentication window 10m, got %s", cfg.ReauthMaxAge)
+	}
+	if cfg.GoogleClientID != "" || cfg.MicrosoftTenant != "common" {
+		t.Errorf("Expected social sign-in disabled by default, got %q/%q", cfg.GoogleClientID, cfg.MicrosoftTenant)
+	}
 
 	// 2. Test Env Var
-	_ = os.Setenv("JWT_SECRET", "custom-secret")
+	_ = os.Setenv("JWT_SECRET", "custom-secret-of-at-least-32-characters")
 	_ = os.Setenv("DATABASE_URL", "postgres://...")
 	_ = os.Setenv("PORT", "9000")
 	_ = os.Setenv("ENV", "production")
+	_ = os.Setenv("LOCKOUT_THRESHOLD", "3")
+	_ = os.Setenv("LOCKOUT_BASE_DURATION", "30s")
+	_ = os.Setenv("PASSWORD_REQUIRE_SPECIAL", "false")
+	_ = os.Setenv("GOOGLE_CLIENT_ID", "google-client")
+	_ = os.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com, https://admin.example.com,")
 
-	cfg = Load()
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Expected a valid production configuration, got %v", err)
+	}
 
-	if cfg.JWTSecret != "custom-secret" {
+	if cfg.JWTSecret != "custom-secret-of-at-least-32-characters" {
 		t.Errorf("Expected custom secret, got %s", cfg.JWTSecret)
 	}
-	if cfg.Port != "9000" {
-		t.Errorf("Expected port 9000, got %s", cfg.Port)
+	if cfg.Port != 9000 {
+		t.Errorf("Expected port 9000, got %d", cfg.Port)
 	}
-	if cfg.Env != "production" {
+	if cfg.Env != "production" || cfg.IsDevelopment() {
 		t.Errorf("Expected env production, got %s", cfg.Env)
 	}
+	if len(cfg.CORSAllowedOrigins) != 2 || cfg.CORSAllowedOrigins[1] != "https://admin.example.com" {
+		t.Errorf("Expected two CORS origins, got %v", cfg.CORSAllowedOrigins)
+	}
+	if cfg.LockoutThreshold != 3 {
+		t.Errorf("Expected lockout threshold 3, got %d", cfg.LockoutThreshold)
+	}
+	if cfg.LockoutBaseDuration != 30*time.Second {
+		t.Errorf("Expected lockout base 30s, got %s", cfg.LockoutBaseDuration)
+	}
+	if cfg.PasswordRequireSpecial {
+		t.Error("Expected special characters to be optional")
+	}
+	if cfg.GoogleClientID != "google-client" {
+		t.Errorf("Expected google client ID, got %q", cfg.GoogleClientID)
+	}
+}
+
+func TestLoad_ProductionRefusesDefaults(t *testing.T) {
+	t.Setenv("ENV", "production")
+	t.Setenv("DATABASE_URL", defaultDatabaseURL)
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com")
+
+	for _, secret := range []string{defaultJWTSecret, "short-secret"} {
+		t.Setenv("JWT_SECRET", secret)
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "JWT_SECRET") || !strings.Contains(err.Error(), "DATABASE_URL") {
+			t.Errorf("Expected JWT_SECRET %q and the default DATABASE_URL to be refused, got %v", secret, err)
+		}
+	}
+
+	// The same values are fine for development
+	t.Setenv("ENV", "development")
+	if _, err := Load(); err != nil {
+		t.Errorf("Expected development defaults to be accepted, got %v", err)
+	}
+}
+
+func TestLoad_InvalidValues(t *testing.T) {
+	t.Setenv("ENV", "development")
+	t.Setenv("PORT", "eighty")
+	t.Setenv("LOCKOUT_BASE_DURATION", "a minute")
+	t.Setenv("DB_MIN_CONNS", "50")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	t.Setenv("BCRYPT_COST", "20")
+	t.Setenv("ARGON2_ITERATIONS", "50")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	// Every problem is reported at once
+	for _, key := range []string{"PORT", "LOCKOUT_BASE_DURATION", "DB_MIN_CONNS", "CORS_ALLOWED_ORIGINS", "BCRYPT_COST", "ARGON2_ITERATIONS"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected %s to be reported, got %v", key, err)
+		}
+	}
+}
+
+func TestLoad_SecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt_secret")
+	if err := os.WriteFile(path, []byte("secret-from-a-mounted-file-0123456789\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ENV", "development")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "")
+	_ = os.Unsetenv("JWT_SECRET")
+	t.Setenv("JWT_SECRET_FILE", path)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.JWTSecret != "secret-from-a-mounted-file-0123456789" {
+		t.Errorf("Expected the secret from the file, got %q", cfg.JWTSecret)
+	}
+
+	// Both set is ambiguous
+	t.Setenv("JWT_SECRET", "another-secret")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "JWT_SECRET_FILE") {
+		t.Errorf("Expected JWT_SECRET and JWT_SECRET_FILE to conflict, got %v", err)
+	}
+}
+
+func TestSummary_Redacts(t *testing.T) {
+	cfg := &Config{
+		Env:          "production",
+		DatabaseURL:  "postgres://salvia:db-password@db:5432/salvia",
+		JWTSecret:    "jwt-secret-value",
+		SMTPPassword: "smtp-password",
+	}
+
+	summary := cfg.Summary()
+	for _, secret := range []string{"db-password", "jwt-secret-value", "smtp-password"} {
+		if strings.Contains(summary, secret) {
+			t.Errorf("Expected %q to be redacted from:\n%s", secret, summary)
+		}
+	}
+	if !strings.Contains(summary, "postgres://salvia:xxxxx@db:5432/salvia") {
+		t.Errorf("Expected the database URL without its password, got:\n%s", summary)
+	}
 }
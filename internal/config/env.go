@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// env reads typed environment variables, collecting every invalid value rather than stopping at the first,
+// so that a misconfigured deployment reports all of its problems at once.
+type env struct {
+	errs []error
+}
+
+// fail records an invalid value.
+func (e *env) fail(key, value, want string) {
+	e.errs = append(e.errs, fmt.Errorf("%s=%q: %s", key, value, want))
+}
+
+// str retrieves a string variable or returns fallback if it is not set.
+func (e *env) str(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// secret retrieves a secret from key, or from the file named by key_FILE (trailing newlines removed),
+// or returns fallback if neither is set.
+func (e *env) secret(key, fallback string) string {
+	path, ok := os.LookupEnv(key + "_FILE")
+	if !ok {
+		return e.str(key, fallback)
+	}
+	if _, both := os.LookupEnv(key); both {
+		e.errs = append(e.errs, fmt.Errorf("%s and %s_FILE: only one may be set", key, key))
+		return fallback
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s_FILE: %w", key, err))
+		return fallback
+	}
+	return strings.TrimRight(string(b), "\r\n")
+}
+
+// integer retrieves an integer variable within [lo, hi] or returns fallback if it is not set.
+func (e *env) integer(key string, fallback, lo, hi int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lo || n > hi {
+		e.fail(key, value, fmt.Sprintf("must be an integer from %d to %d", lo, hi))
+		return fallback
+	}
+	return n
+}
+
+// boolean retrieves a boolean variable (e.g. "true", "0") or returns fallback if it is not set.
+func (e *env) boolean(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		e.fail(key, value, "must be true or false")
+		return fallback
+	}
+	return b
+}
+
+// duration retrieves a duration variable (e.g. "15m") or returns fallback if it is not set.
+func (e *env) duration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		e.fail(key, value, "must be a duration like 90s, 15m or 24h")
+		return fallback
+	}
+	return d
+}
+
+// list retrieves a comma-separated variable or returns fallback if it is unset or empty.
+// Items are trimmed and empty items dropped.
+func (e *env) list(key string, fallback []string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	if len(list) == 0 {
+		return fallback
+	}
+	return list
+}
@@ -14,9 +14,17 @@ type Postgres struct {
 	Pool *pgxpool.Pool
 }
 
+// PoolOptions sizes the connection pool. Zero fields keep the defaults tuned for production.
+type PoolOptions struct {
+	MaxConns        int
+	MinConns        int
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+}
+
 // New creates a new Postgres connection pool with optimized production settings.
 // It parses the connection string, sets connection limits, and verifies the connection with a Ping.
-func New(ctx context.Context, connectionString string) (*Postgres, error) {
+func New(ctx context.Context, connectionString string, opts PoolOptions) (*Postgres, error) {
 	// 1. Parse the URL into a config object
 	config, err := pgxpool.ParseConfig(connectionString)
 	if err != nil {
@@ -28,6 +36,18 @@ func New(ctx context.Context, connectionString string) (*Postgres, error) {
 	config.MinConns = 2                // Keep a few ready
 	config.MaxConnLifetime = time.Hour // Refresh connections occasionally
 	config.MaxConnIdleTime = 30 * time.Minute
+	if opts.MaxConns > 0 {
+		config.MaxConns = int32(opts.MaxConns)
+	}
+	if opts.MinConns > 0 {
+		config.MinConns = int32(opts.MinConns)
+	}
+	if opts.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = opts.MaxConnLifetime
+	}
+	if opts.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = opts.MaxConnIdleTime
+	}
 
 	// 3. Connect!
 	pool, err := pgxpool.NewWithConfig(ctx, config)
@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/authz"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// APIKeyHandler manages the API keys services (transcription workers, the EHR bridge) use instead of a login.
+// It also resolves keys presented as bearer tokens, see auth.Verifier.WithAPIKeys.
+type APIKeyHandler struct {
+	APIKeys   *repository.APIKeyRepository
+	Activity  *repository.ActivityLogRepository
+	Validator *validator.Validate
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler.
+func NewAPIKeyHandler(apiKeyEq *repository.APIKeyRepository, activityEq *repository.ActivityLogRepository) *APIKeyHandler {
+	return &APIKeyHandler{
+		APIKeys:   apiKeyEq,
+		Activity:  activityEq,
+		Validator: validator.New(),
+	}
+}
+
+// CreateAPIKeyInput defines the payload for creating an API key.
+type CreateAPIKeyInput struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Capabilities  []string `json:"capabilities" validate:"required,min=1,dive,required"` // e.g. ["notes.create"]
+	ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1,max=730"`   // Optional, never expires by default
+}
+
+// Create creates an API key for the caller's organization.
+// Requires the "staff.manage" permission.
+// @Summary Create API key
+// @Description Returns the key once; only its hash is stored. The key may only hold capabilities the caller holds,
+// @Description and none requiring a recent authentication (e.g. notes.delete), which a service cannot provide.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body CreateAPIKeyInput true "Key name, capabilities and expiry"
+// @Success 201 {object} response.Response{data=map[string]interface{}} "API key"
+// @Failure 400 {object} response.Response "Invalid capabilities"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Router /api-keys [post]
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	// 1. Only people create keys, and only with their own capabilities
+	staff, ok := authz.StaffFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusForbidden, "API keys cannot create API keys")
+		return
+	}
+
+	var input CreateAPIKeyInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	capabilities := make([]string, 0, len(input.Capabilities))
+	seen := make(map[string]bool, len(input.Capabilities))
+	for _, c := range input.Capabilities {
+		switch {
+		case !repository.IsCapability(c):
+			response.Error(w, http.StatusBadRequest, "Unknown capability: "+c)
+			return
+		case repository.IsSensitive(c):
+			response.Error(w, http.StatusBadRequest, "Capability cannot be granted to an API key: "+c)
+			return
+		case staff.Role != authz.RoleAdmin && !staff.Permissions.Allows(c):
+			response.Error(w, http.StatusForbidden, "You do not hold capability: "+c)
+			return
+		}
+		if !seen[c] {
+			seen[c] = true
+			capabilities = append(capabilities, c)
+		}
+	}
+
+	// 2. Generate and store its hash
+	key, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Key generation failed")
+		return
+	}
+
+	apiKey := &repository.APIKey{
+		OrganizationID: claims.OrgID,
+		Name:           input.Name,
+		Prefix:         prefix,
+		Capabilities:   capabilities,
+		CreatedBy:      &claims.UserID,
+	}
+	if input.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, input.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+	if err := h.APIKeys.CreateAPIKey(r.Context(), apiKey, auth.HashAPIKey(key)); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	// 3. Audit
+	if err := h.record(r, &claims.UserID, nil, apiKey, repository.ActionAPIKeyCreate, "API key created", map[string]interface{}{
+		"name":         apiKey.Name,
+		"capabilities": apiKey.Capabilities,
+		"expires_at":   apiKey.ExpiresAt,
+	}); err != nil {
+		log.Printf("Failed to record creation of API key %s: %v", apiKey.ID, err)
+	}
+
+	response.JSON(w, http.StatusCreated, map[string]interface{}{
+		"api_key": apiKey,
+		"key":     key,
+	})
+}
+
+// List lists the API keys of the caller's organization.
+// Requires the "staff.manage" permission.
+// @Summary List API keys
+// @Description Returns every key of the organization, revoked and expired ones included, newest first. Keys themselves are never returned.
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=map[string][]repository.APIKey} "API keys"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Router /api-keys [get]
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	keys, err := h.APIKeys.ListOrgAPIKeys(r.Context(), claims.OrgID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to list API keys")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{"api_keys": keys})
+}
+
+// Revoke revokes an API key of the caller's organization. It stops working immediately.
+// Requires the "staff.manage" permission.
+// @Summary Revoke API key
+// @Description Revokes an API key. Revoking a revoked key is a no-op.
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} response.Response{data=map[string]repository.APIKey} "API key revoked"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 404 {object} response.Response "Not Found"
+// @Router /api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	id := chi.URLParam(r, "id")
+	if h.Validator.Var(id, "uuid") != nil {
+		response.Error(w, http.StatusNotFound, "API key not found")
+		return
+	}
+
+	apiKey, err := h.APIKeys.RevokeAPIKey(r.Context(), claims.OrgID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			response.Error(w, http.StatusNotFound, "API key not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+
+	userID, apiKeyID := activityActor(claims)
+	if err := h.record(r, userID, apiKeyID, apiKey, repository.ActionAPIKeyRevoke, "API key revoked", nil); err != nil {
+		log.Printf("Failed to record revocation of API key %s: %v", apiKey.ID, err)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{"api_key": apiKey})
+}
+
+// ResolveAPIKey implements auth.APIKeyResolver: it turns an active key into the claims of its organization and capabilities.
+func (h *APIKeyHandler) ResolveAPIKey(ctx context.Context, prefix, hash string) (*auth.Claims, error) {
+	apiKey, err := h.APIKeys.UseAPIKey(ctx, prefix, hash)
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, auth.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	return &auth.Claims{
+		OrgID:        apiKey.OrganizationID,
+		APIKeyID:     apiKey.ID,
+		Capabilities: apiKey.Capabilities,
+	}, nil
+}
+
+// AuditWrites is a middleware recording every write made with an API key, whatever its outcome,
+// with the key in activity_log.api_key_id. It must run after auth.Authenticate.
+func (h *APIKeyHandler) AuditWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := auth.ClaimsFromContext(r.Context())
+		if !ok || !claims.IsAPIKey() || isReadOnlyMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		apiKey := &repository.APIKey{ID: claims.APIKeyID, OrganizationID: claims.OrgID}
+		err := h.record(r, nil, &claims.APIKeyID, apiKey, repository.ActionAPIKeyWrite, r.Method+" "+r.URL.Path, map[string]interface{}{
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"status": status,
+		})
+		if err != nil {
+			log.Printf("Failed to record %s %s of API key %s: %v", r.Method, r.URL.Path, claims.APIKeyID, err)
+		}
+	})
+}
+
+// record writes an entry about apiKey, acted by userID or apiKeyID.
+func (h *APIKeyHandler) record(r *http.Request, userID, apiKeyID *string, apiKey *repository.APIKey, action, description string, changes map[string]interface{}) error {
+	entityType := "api_key"
+	client := sessionClient(r)
+	entry := &repository.ActivityEntry{
+		OrganizationID: &apiKey.OrganizationID,
+		UserID:         userID,
+		APIKeyID:       apiKeyID,
+		Action:         action,
+		EntityType:     &entityType,
+		EntityID:       &apiKey.ID,
+		Description:    description,
+		Changes:        changes,
+	}
+	if client.UserAgent != "" {
+		entry.UserAgent = &client.UserAgent
+	}
+	if client.IPAddress != "" {
+		entry.IPAddress = &client.IPAddress
+	}
+	// The response may already be written: don't lose the entry to a client hanging up
+	return h.Activity.Record(context.WithoutCancel(r.Context()), entry)
+}
+
+// activityActor returns who acts with claims, for activity_log.user_id and api_key_id.
+func activityActor(claims *auth.Claims) (userID, apiKeyID *string) {
+	if claims.IsAPIKey() {
+		return nil, &claims.APIKeyID
+	}
+	return &claims.UserID, nil
+}
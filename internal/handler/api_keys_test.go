@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/authz"
+	"github.com/off-by-2/sal/internal/repository"
+)
+
+func TestAPIKeyIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	authHandler := newTestAuthHandler(db)
+	h := NewAPIKeyHandler(repository.NewAPIKeyRepository(db), repository.NewActivityLogRepository(db))
+	az := authz.NewAuthorizer(authHandler.StaffRepo, time.Minute, 0)
+	verifier := authHandler.Verifier.WithAPIKeys(h)
+
+	// protect puts fn behind the same middleware as the API routes
+	protect := func(capability string, fn http.HandlerFunc) http.HandlerFunc {
+		return auth.Authenticate(verifier)(h.AuditWrites(az.RequirePermission(capability)(fn))).ServeHTTP
+	}
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) }
+
+	admin := loginTestUser(t, authHandler, registerTestUser(t, authHandler, "apikeys")).Data["access_token"].(string)
+	create := protect(repository.CapStaffManage, h.Create)
+
+	// 1. Sensitive and unknown capabilities are refused
+	for _, c := range []string{repository.CapNotesDelete, "notes.everything"} {
+		if code, _ := postWithBearer(t, create, admin, map[string]interface{}{"name": "worker", "capabilities": []string{c}}); code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", c, code)
+		}
+	}
+
+	// 2. Create; the key is only shown now
+	code, resp := postWithBearer(t, create, admin, map[string]interface{}{
+		"name":            "Transcription worker",
+		"capabilities":    []string{repository.CapNotesCreate},
+		"expires_in_days": 30,
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %v", code, resp.Data)
+	}
+	key := resp.Data["key"].(string)
+	keyID := resp.Data["api_key"].(map[string]interface{})["id"].(string)
+
+	// 3. The key authenticates, within its capabilities, and not on account routes
+	if code, _ := postWithBearer(t, protect(repository.CapNotesCreate, ok), key, nil); code != http.StatusNoContent {
+		t.Errorf("Expected 204 for a granted capability, got %d", code)
+	}
+	if code, _ := postWithBearer(t, protect(repository.CapNotesRead, ok), key, nil); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for another capability, got %d", code)
+	}
+	if code, _ := postWithBearer(t, authMiddleware(authHandler, ok), key, nil); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 where API keys are not accepted, got %d", code)
+	}
+
+	// 4. Its use is recorded
+	keys, err := h.APIKeys.ListOrgAPIKeys(ctx, resp.Data["api_key"].(map[string]interface{})["organization_id"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].LastUsedAt == nil || keys[0].ExpiresAt == nil {
+		t.Errorf("Unexpected keys: %+v", keys)
+	}
+	entries, err := h.Activity.ListEntityActivity(ctx, "api_key", keyID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var writes int
+	for _, e := range entries {
+		if e.Action == repository.ActionAPIKeyWrite {
+			writes++
+			if e.APIKeyID == nil || *e.APIKeyID != keyID || e.UserID != nil {
+				t.Errorf("Expected the write to be attributed to the key, got %+v", e)
+			}
+		}
+	}
+	if writes != 2 {
+		t.Errorf("Expected 2 recorded writes, got %d", writes)
+	}
+
+	// 5. Revoked keys stop working
+	if _, err := h.APIKeys.RevokeAPIKey(ctx, keys[0].OrganizationID, keyID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(ctx, key); err == nil {
+		t.Error("Expected a revoked key to be refused")
+	}
+}
+
+func TestAPIKeyCreate_ByAPIKey(t *testing.T) {
+	h := NewAPIKeyHandler(nil, nil)
+
+	req := httptest.NewRequest("POST", "/api-keys", nil)
+	claims := &auth.Claims{OrgID: "org-1", APIKeyID: "key-1", Capabilities: []string{repository.CapStaffManage}}
+	rr := httptest.NewRecorder()
+	h.Create(rr, req.WithContext(auth.WithClaims(req.Context(), claims)))
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403, got %d", rr.Code)
+	}
+}
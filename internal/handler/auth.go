@@ -2,15 +2,26 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 
 	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/config"
 	"github.com/off-by-2/sal/internal/database"
+	"github.com/off-by-2/sal/internal/mail"
+	"github.com/off-by-2/sal/internal/oidc"
+	"github.com/off-by-2/sal/internal/ratelimit"
 	"github.com/off-by-2/sal/internal/repository"
 	"github.com/off-by-2/sal/internal/response"
 )
@@ -21,8 +32,35 @@ type AuthHandler struct {
 	UserRepo  *repository.UserRepository
 	OrgRepo   *repository.OrganizationRepository
 	StaffRepo *repository.StaffRepository
-	JWTSecret string
+	Sessions  *repository.SessionRepository
+	Verifier  *auth.Verifier
+	Keys      *auth.KeyRing // Signs access and challenge tokens
+	JWTSecret string        // Signs short-lived internal state, such as social sign-in flows
+	Lockout   auth.LockoutPolicy
+	Passwords auth.PasswordPolicy
+	Hasher    auth.PasswordHasher
+	Mailer    mail.Mailer
+	AppURL    string                    // Base URL of the web app, for links in emails
+	OIDC      map[string]*oidc.Provider // Enabled social sign-in providers, by name
 	Validator *validator.Validate
+
+	// Lifetimes of access tokens and of sessions (refresh tokens).
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// SecureCookies restricts cookies to HTTPS. It is off in development only, which runs over plain HTTP.
+	SecureCookies bool
+
+	// unknownEmails locks out unregistered emails like real accounts, see auth.FailureTracker.
+	unknownEmails *auth.FailureTracker
+	// resendLimiter limits verification emails per user.
+	resendLimiter *ratelimit.Limiter
+	// resetLimiter limits password reset emails per address.
+	resetLimiter *ratelimit.Limiter
+	// tasks tracks work that outlives its request, such as sending reset emails.
+	tasks sync.WaitGroup
+	// dummyHash is compared against when there is no password to check,
+	// so that unknown emails and provider-only accounts take the same time to reject.
+	dummyHash func() string
 }
 
 // NewAuthHandler creates a new AuthHandler.
@@ -31,22 +69,105 @@ func NewAuthHandler(
 	userEq *repository.UserRepository,
 	orgEq *repository.OrganizationRepository,
 	staffEq *repository.StaffRepository,
-	jwtSecret string,
+	sessionEq *repository.SessionRepository,
+	mailer mail.Mailer,
+	keys *auth.KeyRing,
+	cfg *config.Config,
 ) *AuthHandler {
+	lockout := auth.LockoutPolicy{
+		Threshold: cfg.LockoutThreshold,
+		BaseDelay: cfg.LockoutBaseDuration,
+		MaxDelay:  cfg.LockoutMaxDuration,
+	}
+
+	passwords := auth.PasswordPolicy{
+		MinLength:      cfg.PasswordMinLength,
+		MaxLength:      72,
+		RequireUpper:   cfg.PasswordRequireUpper,
+		RequireLower:   cfg.PasswordRequireLower,
+		RequireNumber:  cfg.PasswordRequireNumber,
+		RequireSpecial: cfg.PasswordRequireSpecial,
+		RejectCommon:   cfg.PasswordRejectCommon,
+		RejectPersonal: cfg.PasswordRejectPersonal,
+	}
+
+	hasher := auth.PasswordHasher{
+		Algorithm: cfg.PasswordHashAlgorithm,
+		Argon2: auth.Argon2Params{
+			Memory:      cfg.Argon2MemoryKiB,
+			Iterations:  cfg.Argon2Iterations,
+			Parallelism: cfg.Argon2Parallelism,
+			SaltLen:     16,
+			KeyLen:      32,
+		},
+		BcryptCost: cfg.BcryptCost,
+	}
+	if err := hasher.Validate(); err != nil {
+		panic(err)
+	}
+
+	v := validator.New()
+	if err := passwords.RegisterValidators(v); err != nil {
+		panic(err)
+	}
+	v.RegisterStructValidation(func(sl validator.StructLevel) {
+		in := sl.Current().Interface().(RegisterInput)
+		if passwords.ContainsPersonal(in.Password, in.Email, in.OrgName) {
+			sl.ReportError(in.Password, "Password", "Password", auth.TagPersonalPassword, "")
+		}
+	}, RegisterInput{})
+
 	return &AuthHandler{
-		DB:        db,
-		UserRepo:  userEq,
-		OrgRepo:   orgEq,
-		StaffRepo: staffEq,
-		JWTSecret: jwtSecret,
-		Validator: validator.New(),
+		DB:            db,
+		UserRepo:      userEq,
+		OrgRepo:       orgEq,
+		StaffRepo:     staffEq,
+		Sessions:      sessionEq,
+		Verifier:      auth.NewVerifier(keys, sessionEq, orgEq),
+		Keys:          keys,
+		JWTSecret:     cfg.JWTSecret,
+		SecureCookies: !cfg.IsDevelopment(),
+		Lockout:       lockout,
+		Passwords:     passwords,
+		Hasher:        hasher,
+		Mailer:        mailer,
+		AppURL:        cfg.AppURL,
+		OIDC:          oidcProviders(cfg),
+		Validator:     v,
+		unknownEmails: auth.NewFailureTracker(lockout),
+		resendLimiter: ratelimit.New(verificationResendLimit, verificationResendWindow),
+		resetLimiter:  ratelimit.New(passwordResetLimit, passwordResetWindow),
+
+		AccessTokenTTL:  cfg.AccessTokenTTL,
+		RefreshTokenTTL: cfg.RefreshTokenTTL,
+
+		dummyHash: sync.OnceValue(func() string {
+			hash, _ := hasher.Hash("dummy-password-for-timing")
+			return hash
+		}),
+	}
+}
+
+// Wait blocks until the work that outlives requests, such as sending reset emails, is done, or ctx ends.
+// Call it once the HTTP server stopped accepting requests.
+func (h *AuthHandler) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.tasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
 // RegisterInput defines the payload for admin registration.
 type RegisterInput struct {
 	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"password" validate:"required,min=8"`
+	Password  string `json:"password" validate:"required,password"`
 	FirstName string `json:"first_name" validate:"required"`
 	LastName  string `json:"last_name" validate:"required"`
 	OrgName   string `json:"org_name" validate:"required"`
@@ -56,11 +177,34 @@ type RegisterInput struct {
 type LoginInput struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	DeviceID string `json:"device_id" validate:"max=255"`     // Optional, identifies the client device
+	OrgID    string `json:"org_id" validate:"omitempty,uuid"` // Optional, defaults to the oldest membership
+}
+
+// SwitchOrgInput defines the payload for switching the organization of the current session.
+type SwitchOrgInput struct {
+	OrgID string `json:"org_id" validate:"required,uuid"`
+}
+
+// RefreshInput defines the payload for refreshing tokens and logging out.
+// Browser clients may send an empty body and rely on the refresh_token cookie instead.
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
+const (
+	// refreshCookieName is the name of the HTTP-only cookie carrying the refresh token.
+	refreshCookieName = "refresh_token"
+	// refreshCookiePath limits the refresh cookie to the auth routes (refresh and logout).
+	refreshCookiePath = "/api/v1/auth"
+	// lockedMessage is returned while an account is locked out.
+	// It is identical for registered and unknown emails.
+	lockedMessage = "Account temporarily locked. Try again later."
+)
+
 // Register creates a new user, organization, and admin staff entry atomically.
 // @Summary Register a new Admin
-// @Description Creates a new User, Organization, and links them as Admin Staff.
+// @Description Creates a new User, Organization, and links them as Admin Staff, and emails a verification link.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -82,7 +226,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 1. Hash Password
-	hashedPW, err := auth.HashPassword(input.Password)
+	hashedPW, err := h.Hasher.Hash(input.Password)
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, "Failed to process password")
 		return
@@ -143,6 +287,11 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// D. Ask the user to verify their email. The account works without it, so a mail failure is not fatal.
+	if err := h.sendVerificationEmail(r.Context(), userID, input.Email, input.FirstName); err != nil {
+		log.Printf("Failed to send verification email to user %s: %v", userID, err)
+	}
+
 	response.JSON(w, http.StatusCreated, map[string]string{
 		"user_id": userID,
 		"org_id":  orgID,
@@ -152,13 +301,21 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 // Login authenticates a user and returns tokens.
 // @Summary Login
-// @Description Authenticates user by email/password and returns JWT pairs.
+// @Description Authenticates user by email/password and returns JWT pairs together with the user's memberships.
+// @Description The tokens are scoped to org_id if given, otherwise to the oldest membership.
+// @Description If the user has two-factor authentication, a challenge token for POST /auth/2fa/verify is returned instead (mfa_required).
+// @Description If their organization requires two-factor authentication and they have not enrolled, a challenge token for
+// @Description POST /auth/2fa/enroll is returned instead (mfa_enrollment_required).
+// @Description Suspended organizations are skipped, or refused with code organization_suspended when requested or the only ones;
+// @Description those suspended in read-only mode stay accessible (memberships report suspended and read_only).
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param input body LoginInput true "Login Credentials"
-// @Success 200 {object} response.Response{data=map[string]string} "Tokens"
+// @Success 200 {object} response.Response{data=map[string]interface{}} "Tokens or two-factor challenge"
 // @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Not a member of org_id, or organization suspended"
+// @Failure 429 {object} response.Response "Account temporarily locked"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var input LoginInput
@@ -175,68 +332,616 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	// 1. Get User
 	user, err := h.UserRepo.GetUserByEmail(r.Context(), input.Email)
 	if err != nil {
-		// Security: Don't reveal if user exists vs wrong password
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			response.Error(w, http.StatusInternalServerError, "Failed to load user")
+			return
+		}
+		// Security: Don't reveal if user exists vs wrong password.
+		// Unknown emails are locked out and hashed exactly like real accounts.
+		if until := h.unknownEmails.LockedUntil(input.Email); !until.IsZero() {
+			lockedError(w, until)
+			return
+		}
+		_ = auth.CheckPasswordHash(input.Password, h.dummyHash())
+		if until := h.unknownEmails.Fail(input.Email); !until.IsZero() {
+			lockedError(w, until)
+			return
+		}
 		response.Error(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
-	// 2. Check Password
+	// 2. Check Lockout (before the password, so a locked account cannot be brute-forced)
+	if user.IsLocked() {
+		lockedError(w, *user.LockedUntil)
+		return
+	}
+
+	// 3. Check Password
+	// Accounts created through a sign-in provider have no password and can only sign in there.
+	// They are rejected like a wrong password, but without counting towards the lockout.
+	if user.PasswordHash == "" {
+		_ = auth.CheckPasswordHash(input.Password, h.dummyHash())
+		response.Error(w, http.StatusUnauthorized, "Invalid credentials")
+		return
+	}
 	if err := auth.CheckPasswordHash(input.Password, user.PasswordHash); err != nil {
+		lockedUntil, err := h.UserRepo.RecordFailedLogin(r.Context(), user.ID, h.Lockout.LockDuration)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to record login attempt")
+			return
+		}
+		if lockedUntil != nil {
+			lockedError(w, *lockedUntil)
+			return
+		}
 		response.Error(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
-	// 3. Check Active
+	// 4. Check Active
 	if !user.IsActive {
 		response.Error(w, http.StatusUnauthorized, "Account is inactive")
 		return
 	}
 
-	// 4. Resolve Context (Org & Role)
-	// For now, we pick the first organization they are staff of.
-	// In the future, Login might return a list of orgs to choose from, or require an OrgID header.
+	// 5. Upgrade the hash if it predates the current algorithm or parameters.
+	// The login does not depend on it, so a failure is only logged.
+	if h.Hasher.NeedsRehash(user.PasswordHash) {
+		if hash, err := h.Hasher.Hash(input.Password); err != nil {
+			log.Printf("Failed to rehash password of user %s: %v", user.ID, err)
+		} else if err := h.UserRepo.RehashPassword(r.Context(), user.ID, user.PasswordHash, hash); err != nil {
+			log.Printf("Failed to rehash password of user %s: %v", user.ID, err)
+		}
+	}
+
+	h.completeLogin(w, r, user, input.OrgID, input.DeviceID, []string{auth.AMRPassword})
+}
+
+// completeLogin finishes a login once the user has proven who they are with amr (password or sign-in provider).
+// It resolves the organization, asks for the second factor when needed and otherwise starts a session.
+func (h *AuthHandler) completeLogin(w http.ResponseWriter, r *http.Request, user *repository.User, requestedOrgID, deviceID string, amr []string) {
+	// 1. Resolve Context (Org & Role)
+	// The requested organization, or the oldest membership if none was requested.
+	// Users without any membership get a token without organization.
+	memberships, err := h.StaffRepo.ListMembershipsByUser(r.Context(), user.ID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to load memberships")
+		return
+	}
+
+	// Suspended organizations are refused, unless suspended in read-only mode.
 	var orgID, role string
-	err = h.DB.Pool.QueryRow(r.Context(), `
-		SELECT organization_id, role FROM staff WHERE user_id = $1 LIMIT 1`,
-		user.ID,
-	).Scan(&orgID, &role)
+	if requestedOrgID != "" {
+		m := findMembership(memberships, requestedOrgID)
+		if m == nil {
+			response.Error(w, http.StatusForbidden, "Not an active member of this organization")
+			return
+		}
+		if m.Suspended && !m.ReadOnly {
+			orgSuspendedError(w)
+			return
+		}
+		orgID, role = m.OrganizationID, m.Role
+	} else if len(memberships) > 0 {
+		m := firstAccessibleMembership(memberships)
+		if m == nil {
+			orgSuspendedError(w)
+			return
+		}
+		orgID, role = m.OrganizationID, m.Role
+	}
+
+	// 2. Second Factor: users with 2FA (or who must enrol) only get a challenge token here
+	if user.TwoFactorEnabled {
+		h.twoFactorChallenge(w, auth.Claims{UserID: user.ID, OrgID: orgID, Role: role, AMR: amr}, auth.PurposeMFA, memberships)
+		return
+	}
+	if orgID != "" {
+		required, err := h.OrgRepo.RequiresTwoFactor(r.Context(), orgID)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to load organization")
+			return
+		}
+		if required {
+			h.twoFactorChallenge(w, auth.Claims{UserID: user.ID, OrgID: orgID, Role: role, AMR: amr}, auth.PurposeMFAEnroll, memberships)
+			return
+		}
+	}
+
+	// 3. Create Session and Tokens
+	tokens, err := h.startSession(w, r, user.ID, orgID, role, deviceID, amr)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+	tokens["memberships"] = memberships
+
+	response.JSON(w, http.StatusOK, tokens)
+}
+
+// findMembership returns the membership in orgID, or nil.
+func findMembership(memberships []repository.Membership, orgID string) *repository.Membership {
+	for i := range memberships {
+		if memberships[i].OrganizationID == orgID {
+			return &memberships[i]
+		}
+	}
+	return nil
+}
+
+// firstAccessibleMembership returns the oldest membership in an organization that is not suspended
+// (or only read-only), or nil.
+func firstAccessibleMembership(memberships []repository.Membership) *repository.Membership {
+	for i := range memberships {
+		if !memberships[i].Suspended || memberships[i].ReadOnly {
+			return &memberships[i]
+		}
+	}
+	return nil
+}
+
+// orgSuspendedError answers a request for a suspended organization.
+func orgSuspendedError(w http.ResponseWriter) {
+	response.ErrorCode(w, http.StatusForbidden, auth.CodeOrgSuspended, "Organization is suspended")
+}
+
+// startSession completes a login with the methods in amr: it clears the lockout counter, persists a new session,
+// sets the refresh cookie and returns the token pair.
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, userID, orgID, role, deviceID string, amr []string) (map[string]interface{}, error) {
+	if err := h.UserRepo.RecordSuccessfulLogin(r.Context(), userID); err != nil {
+		return nil, err
+	}
+
+	// 1. Generate Refresh Token and persist the session with its hash
+	refreshToken, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &repository.Session{
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(h.RefreshTokenTTL),
+		AMR:       amr,
+	}
+	if orgID != "" {
+		session.OrganizationID = &orgID
+	}
+	if deviceID != "" {
+		session.DeviceID = &deviceID
+	}
+	client := sessionClient(r)
+	if client.UserAgent != "" {
+		session.UserAgent = &client.UserAgent
+	}
+	if client.IPAddress != "" {
+		session.IPAddress = &client.IPAddress
+	}
+	if err := h.Sessions.CreateSession(r.Context(), session, auth.HashRefreshToken(refreshToken)); err != nil {
+		return nil, err
+	}
 
+	// 2. Generate Access Token bound to the session
+	claims := auth.Claims{
+		UserID:    userID,
+		OrgID:     orgID,
+		Role:      role,
+		SessionID: session.ID,
+	}
+	claims.SetAuthentication(session.AuthTime, session.AMR)
+	accessToken, err := auth.SignAccessTokenFor(claims, h.AccessTokenTTL, h.Keys)
 	if err != nil {
-		// User exists but has no staff record (e.g. beneficiary or system admin/orphan)
-		// We can issue a token with no org_id, but for now fallback to guest/error
-		// response.Error(w, http.StatusForbidden, "User is not assigned to any organization")
-		// return
-		role = "guest"
+		return nil, err
 	}
 
-	// 5. Generate Tokens
-	accessToken, err := auth.NewAccessToken(user.ID, orgID, role, h.JWTSecret)
+	// 3. Set Refresh and CSRF Cookies
+	csrfToken, err := h.setRefreshCookie(w, r, refreshToken, session.ExpiresAt)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Token generation failed")
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"access_token":    accessToken,
+		"refresh_token":   refreshToken,
+		"csrf_token":      csrfToken,
+		"organization_id": orgID,
+	}, nil
+}
+
+// refreshRefusal is a Refresh refused once the token is known valid, e.g. because the account was deactivated.
+type refreshRefusal struct {
+	status  int
+	code    string
+	message string
+}
+
+// Error implements error.
+func (e *refreshRefusal) Error() string {
+	return e.message
+}
+
+// Refresh rotates a refresh token and issues a new access token.
+// @Summary Refresh tokens
+// @Description Exchanges a refresh token (cookie or body) for a new access/refresh token pair.
+// @Description Each refresh token is single-use; replaying a rotated token revokes the whole session.
+// @Description When the refresh token is sent as cookie, the X-CSRF-Token header must hold the csrf_token cookie.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body RefreshInput false "Refresh Token (optional when sent as cookie)"
+// @Success 200 {object} response.Response{data=map[string]string} "Tokens"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Missing or invalid CSRF token, or organization suspended"
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	presented, err := refreshTokenFromRequest(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if presented == "" {
+		response.Error(w, http.StatusUnauthorized, "Missing refresh token")
 		return
 	}
 
+	// 1. The new token
 	refreshToken, err := auth.NewRefreshToken()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, "Token generation failed")
 		return
 	}
 
-	// TODO: Store Refresh Token hash in DB
+	// 2. Re-check the user and membership, both may have changed since login.
+	// A refusal leaves the presented token unspent, so the session is not mistaken for a replay later.
+	var (
+		user        *repository.User
+		orgID, role string
+	)
+	session, err := h.Sessions.RotateRefreshToken(
+		r.Context(),
+		auth.HashRefreshToken(presented),
+		auth.HashRefreshToken(refreshToken),
+		time.Now().Add(h.RefreshTokenTTL),
+		sessionClient(r),
+		func(s *repository.Session) error {
+			var err error
+			user, err = h.UserRepo.GetUserByID(r.Context(), s.UserID)
+			if err != nil || !user.IsActive {
+				return &refreshRefusal{status: http.StatusUnauthorized, message: "Account is inactive"}
+			}
+			if s.OrganizationID == nil {
+				return nil
+			}
+
+			staff, err := h.StaffRepo.GetStaffByUserAndOrg(r.Context(), user.ID, *s.OrganizationID)
+			if err != nil {
+				return &refreshRefusal{status: http.StatusUnauthorized, message: "Membership is no longer active"}
+			}
+			orgID, role = staff.OrganizationID, staff.Role
+
+			suspended, readOnly, err := h.OrgRepo.OrgSuspension(r.Context(), orgID)
+			if err != nil {
+				return err
+			}
+			if suspended && !readOnly {
+				return &refreshRefusal{status: http.StatusForbidden, code: auth.CodeOrgSuspended, message: "Organization is suspended"}
+			}
+
+			required, err := h.OrgRepo.RequiresTwoFactor(r.Context(), orgID)
+			if err != nil {
+				return err
+			}
+			if required && !user.TwoFactorEnabled {
+				return &refreshRefusal{status: http.StatusForbidden, message: "Two-factor authentication required"}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		var refusal *refreshRefusal
+		switch {
+		case errors.As(err, &refusal):
+			h.clearRefreshCookie(w)
+			if refusal.code != "" {
+				response.ErrorCode(w, refusal.status, refusal.code, refusal.message)
+			} else {
+				response.Error(w, refusal.status, refusal.message)
+			}
+		case errors.Is(err, repository.ErrRefreshTokenNotFound),
+			errors.Is(err, repository.ErrRefreshTokenExpired),
+			errors.Is(err, repository.ErrRefreshTokenReused),
+			errors.Is(err, repository.ErrSessionRevoked):
+			h.clearRefreshCookie(w)
+			response.Error(w, http.StatusUnauthorized, "Invalid refresh token")
+		default:
+			response.Error(w, http.StatusInternalServerError, "Failed to refresh session")
+		}
+		return
+	}
+
+	// 3. Issue Access Token, still carrying the original authentication
+	claims := auth.Claims{
+		UserID:    user.ID,
+		OrgID:     orgID,
+		Role:      role,
+		SessionID: session.ID,
+	}
+	claims.SetAuthentication(session.AuthTime, session.AMR)
+	accessToken, err := auth.SignAccessTokenFor(claims, h.AccessTokenTTL, h.Keys)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Token generation failed")
+		return
+	}
+
+	csrfToken, err := h.setRefreshCookie(w, r, refreshToken, session.ExpiresAt)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Token generation failed")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"csrf_token":    csrfToken,
+	})
+}
+
+// SwitchOrg re-scopes the current session to another organization the user is an active member of.
+// @Summary Switch organization
+// @Description Issues a new access token for the given organization. The session follows, so later refreshes stay in it.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body SwitchOrgInput true "Target organization"
+// @Success 200 {object} response.Response{data=map[string]string} "Access token"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Not a member, or organization suspended"
+// @Router /auth/switch-org [post]
+func (h *AuthHandler) SwitchOrg(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	var input SwitchOrgInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	// 1. Check Membership
+	staff, err := h.StaffRepo.GetStaffByUserAndOrg(r.Context(), claims.UserID, input.OrgID)
+	if err != nil {
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			response.Error(w, http.StatusForbidden, "Not an active member of this organization")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to load membership")
+		return
+	}
+
+	// 2. The target organization may be suspended, or require two-factor authentication
+	suspended, readOnly, err := h.OrgRepo.OrgSuspension(r.Context(), staff.OrganizationID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to load organization")
+		return
+	}
+	if suspended && !readOnly {
+		orgSuspendedError(w)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(r.Context(), claims.UserID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to load user")
+		return
+	}
+	required, err := h.OrgRepo.RequiresTwoFactor(r.Context(), staff.OrganizationID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to load organization")
+		return
+	}
+	if required && !user.TwoFactorEnabled {
+		response.Error(w, http.StatusForbidden, "Two-factor authentication required")
+		return
+	}
+
+	// 3. Move the session and issue a token for it
+	if err := h.Sessions.SetSessionOrganization(r.Context(), claims.SessionID, staff.OrganizationID); err != nil {
+		if errors.Is(err, repository.ErrSessionRevoked) {
+			response.Error(w, http.StatusUnauthorized, "Session has been revoked")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to switch organization")
+		return
+	}
+
+	accessToken, err := auth.SignAccessTokenFor(auth.Claims{
+		UserID:    claims.UserID,
+		OrgID:     staff.OrganizationID,
+		Role:      staff.Role,
+		SessionID: claims.SessionID,
+		AuthTime:  claims.AuthTime,
+		AMR:       claims.AMR,
+	}, h.AccessTokenTTL, h.Keys)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Token generation failed")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{
+		"access_token":    accessToken,
+		"organization_id": staff.OrganizationID,
+	})
+}
+
+// Logout ends the current session.
+// @Summary Logout
+// @Description Revokes the session identified by the refresh token (cookie or body) or, failing that, the bearer access token.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body RefreshInput false "Refresh Token (optional when sent as cookie)"
+// @Success 200 {object} response.Response{data=map[string]string} "Logged out"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Missing or invalid CSRF token"
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.resolveSession(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Sessions.RevokeSession(r.Context(), session.ID, "logout"); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	h.clearRefreshCookie(w)
+	response.JSON(w, http.StatusOK, map[string]string{"message": "Logged out"})
+}
+
+// LogoutAll ends every session of the current user on every device.
+// @Summary Logout everywhere
+// @Description Revokes all sessions of the user identified by the refresh token or bearer access token.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body RefreshInput false "Refresh Token (optional when sent as cookie)"
+// @Success 200 {object} response.Response{data=map[string]interface{}} "Logged out everywhere"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Impersonating, or missing or invalid CSRF token"
+// @Router /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.resolveSession(w, r)
+	if !ok {
+		return
+	}
+	if session.ImpersonatorID != nil {
+		response.Error(w, http.StatusForbidden, auth.ImpersonationDeniedMessage)
+		return
+	}
+
+	revoked, err := h.Sessions.RevokeUserSessions(r.Context(), session.UserID, "logout_all")
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	h.clearRefreshCookie(w)
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"message":          "Logged out from all devices",
+		"revoked_sessions": revoked,
+	})
+}
+
+// normalizeEmail lowercases and trims an email for use as a rate limit key.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// lockedError responds 429 with a Retry-After header until the lock expires.
+func lockedError(w http.ResponseWriter, until time.Time) {
+	retryAfter := int(time.Until(until).Seconds()) + 1
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	response.Error(w, http.StatusTooManyRequests, lockedMessage)
+}
+
+// resolveSession identifies the caller's session for logout.
+// The refresh token is preferred because it still works after the access token expired.
+// It writes an error response and returns false if no live session can be identified.
+func (h *AuthHandler) resolveSession(w http.ResponseWriter, r *http.Request) (*repository.Session, bool) {
+	presented, err := refreshTokenFromRequest(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return nil, false
+	}
+
+	if presented != "" {
+		session, err := h.Sessions.GetSessionByRefreshToken(r.Context(), auth.HashRefreshToken(presented))
+		if err == nil && session.RevokedAt == nil {
+			return session, true
+		}
+		if err != nil && !errors.Is(err, repository.ErrRefreshTokenNotFound) {
+			response.Error(w, http.StatusInternalServerError, "Failed to resolve session")
+			return nil, false
+		}
+	}
+
+	if token := auth.BearerToken(r); token != "" {
+		if claims, err := h.Verifier.Verify(r.Context(), token); err == nil {
+			session := &repository.Session{ID: claims.SessionID, UserID: claims.UserID}
+			if claims.Impersonated() {
+				session.ImpersonatorID = &claims.Actor.UserID
+			}
+			return session, true
+		}
+	}
+
+	h.clearRefreshCookie(w)
+	response.Error(w, http.StatusUnauthorized, "No active session")
+	return nil, false
+}
+
+// refreshTokenFromRequest reads the refresh token from the JSON body, falling back to the cookie.
+// An empty body is allowed.
+func refreshTokenFromRequest(r *http.Request) (string, error) {
+	var input RefreshInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	if input.RefreshToken != "" {
+		return input.RefreshToken, nil
+	}
+	if c, err := r.Cookie(refreshCookieName); err == nil {
+		return c.Value, nil
+	}
+	return "", nil
+}
+
+// setRefreshCookie stores the refresh token in an HTTP-only cookie, along with the CSRF token
+// guarding its use, and returns the CSRF token.
+// It is scoped to the auth routes so it reaches refresh and logout, but no other endpoint.
+func (h *AuthHandler) setRefreshCookie(w http.ResponseWriter, r *http.Request, token string, expires time.Time) (string, error) {
+	csrfToken, err := csrfTokenFromRequest(r)
+	if err != nil {
+		return "", err
+	}
 
-	// 6. Set Refresh Cookie
 	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    refreshToken,
-		Expires:  time.Now().Add(7 * 24 * time.Hour),
+		Name:     refreshCookieName,
+		Value:    token,
+		Expires:  expires,
 		HttpOnly: true,
-		Secure:   false, // Make dynamic based on Env
-		Path:     "/api/v1/auth/refresh",
+		Secure:   h.SecureCookies,
+		Path:     refreshCookiePath,
 		SameSite: http.SameSiteStrictMode,
 	})
+	h.setCSRFCookie(w, csrfToken, expires)
+	return csrfToken, nil
+}
 
-	response.JSON(w, http.StatusOK, map[string]string{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
+// clearRefreshCookie instructs the client to drop the refresh token and CSRF cookies.
+func (h *AuthHandler) clearRefreshCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    "",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.SecureCookies,
+		Path:     refreshCookiePath,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    "",
+		MaxAge:   -1,
+		Secure:   h.SecureCookies,
+		Path:     "/",
+		SameSite: http.SameSiteStrictMode,
 	})
 }
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// BeneficiaryHandler handles beneficiary (patient and resident) requests.
+type BeneficiaryHandler struct {
+	Beneficiaries *repository.BeneficiaryRepository
+	Validator     *validator.Validate
+}
+
+// NewBeneficiaryHandler creates a new BeneficiaryHandler.
+func NewBeneficiaryHandler(beneficiaryEq *repository.BeneficiaryRepository) *BeneficiaryHandler {
+	return &BeneficiaryHandler{
+		Beneficiaries: beneficiaryEq,
+		Validator:     validator.New(),
+	}
+}
+
+// CreateBeneficiaryInput defines the payload for creating a beneficiary.
+type CreateBeneficiaryInput struct {
+	FirstName           string `json:"first_name" validate:"required,max=100"`
+	LastName            string `json:"last_name" validate:"required,max=100"`
+	DateOfBirth         string `json:"date_of_birth" validate:"required,datetime=2006-01-02"`
+	MedicalRecordNumber string `json:"medical_record_number" validate:"required,max=50"`
+	Phone               string `json:"phone" validate:"omitempty,max=20"`
+	Email               string `json:"email" validate:"omitempty,email,max=255"`
+}
+
+// Create adds a beneficiary to the caller's organization.
+// Requires the "beneficiaries.create" permission.
+// @Summary Create beneficiary
+// @Description Beyond the organization's max_beneficiaries, the response is 409 with code quota_exceeded.
+// @Tags beneficiaries
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body CreateBeneficiaryInput true "Beneficiary"
+// @Success 201 {object} response.Response{data=map[string]repository.Beneficiary} "Beneficiary created"
+// @Failure 400 {object} response.Response "Invalid request body"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 409 {object} response.Response "Medical record number taken, or no beneficiary left in the quota"
+// @Failure 422 {object} response.Response "Validation Error"
+// @Router /beneficiaries [post]
+func (h *BeneficiaryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	var input CreateBeneficiaryInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	// Bounds of the beneficiary_dob_* constraints
+	dob, _ := time.Parse(time.DateOnly, input.DateOfBirth)
+	if dob.Year() < 1900 || dob.After(time.Now()) {
+		response.FieldError(w, "DateOfBirth", "range")
+		return
+	}
+
+	b := &repository.Beneficiary{
+		OrganizationID:      claims.OrgID,
+		FirstName:           input.FirstName,
+		LastName:            input.LastName,
+		DateOfBirth:         dob,
+		MedicalRecordNumber: input.MedicalRecordNumber,
+		Phone:               optionalString(input.Phone),
+		Email:               optionalString(input.Email),
+		CreatedBy:           claims.UserID,
+	}
+	if err := h.Beneficiaries.CreateBeneficiary(r.Context(), b); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrMedicalRecordNumberTaken):
+			response.Error(w, http.StatusConflict, "Medical record number already in use")
+		case errors.Is(err, repository.ErrQuotaExceeded):
+			quotaExceeded(w, err)
+		default:
+			response.Error(w, http.StatusInternalServerError, "Failed to create beneficiary")
+		}
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, map[string]interface{}{"beneficiary": b})
+}
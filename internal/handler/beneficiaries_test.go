@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/repository"
+)
+
+func TestCreateBeneficiary_Validation(t *testing.T) {
+	h := NewBeneficiaryHandler(nil) // Every case is refused before the database
+	caller := &auth.Claims{UserID: "user-1", OrgID: "org-1", Role: "staff"}
+	valid := func() map[string]string {
+		return map[string]string{"first_name": "Ada", "last_name": "Byron", "date_of_birth": "1950-12-10", "medical_record_number": "MRN-1"}
+	}
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		field string
+	}{
+		{"Missing Name", "last_name", "", "LastName"},
+		{"Malformed Date", "date_of_birth", "10/12/1950", "DateOfBirth"},
+		{"Future Birth", "date_of_birth", "2999-01-01", "DateOfBirth"},
+		{"Too Old", "date_of_birth", "1850-01-01", "DateOfBirth"},
+		{"Bad Email", "email", "not-an-email", "Email"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			body := valid()
+			body[tc.key] = tc.value
+			code, resp := postWithClaims(t, h.Create, caller, body)
+			if code != http.StatusUnprocessableEntity || resp.Data[tc.field] == nil {
+				t.Errorf("Expected 422 on %s, got %d: %v", tc.field, code, resp.Data)
+			}
+		})
+	}
+}
+
+func TestBeneficiaryIntegration_Quota(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	authHandler := newTestAuthHandler(db)
+	h := NewBeneficiaryHandler(repository.NewBeneficiaryRepository(db))
+
+	user, err := authHandler.UserRepo.GetUserByEmail(ctx, registerTestUser(t, authHandler, "beneficiaries"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orgID string
+	if err := db.Pool.QueryRow(ctx, `SELECT organization_id FROM staff WHERE user_id = $1`, user.ID).Scan(&orgID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Pool.Exec(ctx, `UPDATE organizations SET max_beneficiaries = 1 WHERE id = $1`, orgID); err != nil {
+		t.Fatal(err)
+	}
+	caller := &auth.Claims{UserID: user.ID, OrgID: orgID, Role: "admin"}
+	patient := func(mrn string) map[string]string {
+		return map[string]string{"first_name": "Ada", "last_name": "Byron", "date_of_birth": "1950-12-10", "medical_record_number": mrn}
+	}
+
+	// 1. The one place of the quota
+	if code, resp := postWithClaims(t, h.Create, caller, patient("MRN-1")); code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %v", code, resp.Data)
+	}
+
+	// 2. Beyond it, an upgrade prompt
+	code, resp := postWithClaims(t, h.Create, caller, patient("MRN-2"))
+	if code != http.StatusConflict || resp.Data["code"] != codeQuotaExceeded {
+		t.Errorf("Expected 409 %s, got %d: %v", codeQuotaExceeded, code, resp.Data)
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/off-by-2/sal/internal/response"
+)
+
+const (
+	// csrfCookieName is the cookie carrying the CSRF token. It is readable by scripts on purpose:
+	// the web app echoes it in csrfHeaderName, which a cross-site form or request cannot do.
+	csrfCookieName = "csrf_token"
+	// csrfHeaderName is the request header the CSRF token must be echoed in.
+	csrfHeaderName = "X-CSRF-Token"
+	// codeCSRFFailed is the error code of requests refused by RequireCSRF.
+	codeCSRFFailed = "csrf_token_invalid"
+)
+
+// CSRFToken returns the CSRF token to send with cookie-authenticated requests.
+// @Summary Get CSRF token
+// @Description Returns the token to echo in the X-CSRF-Token header of refresh and logout requests that rely on the
+// @Description refresh_token cookie, e.g. after a page reload. It is also returned by login and refresh.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} response.Response{data=map[string]string} "CSRF token"
+// @Router /auth/csrf [get]
+func (h *AuthHandler) CSRFToken(w http.ResponseWriter, r *http.Request) {
+	token, err := csrfTokenFromRequest(r)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Token generation failed")
+		return
+	}
+	h.setCSRFCookie(w, token, time.Now().Add(h.RefreshTokenTTL))
+
+	response.JSON(w, http.StatusOK, map[string]string{"csrf_token": token})
+}
+
+// RequireCSRF is a middleware enforcing double-submit CSRF protection on requests carrying the refresh cookie:
+// the X-CSRF-Token header must match the csrf_token cookie. Requests without the cookie (the refresh token
+// in the body, or a bearer token) cannot be forged by another site and pass through.
+func (h *AuthHandler) RequireCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie(refreshCookieName); err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := r.Header.Get(csrfHeaderName)
+		cookie, err := r.Cookie(csrfCookieName)
+		if err != nil || header == "" || subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) != 1 {
+			response.ErrorCode(w, http.StatusForbidden, codeCSRFFailed, "Missing or invalid CSRF token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// csrfTokenFromRequest keeps the token of the csrf_token cookie, so that other tabs holding it keep working,
+// or generates a new one.
+func csrfTokenFromRequest(r *http.Request) (string, error) {
+	if c, err := r.Cookie(csrfCookieName); err == nil && len(c.Value) == 64 {
+		if _, err := hex.DecodeString(c.Value); err == nil {
+			return c.Value, nil
+		}
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// setCSRFCookie stores the CSRF token in a cookie readable by the web app.
+func (h *AuthHandler) setCSRFCookie(w http.ResponseWriter, token string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    token,
+		Expires:  expires,
+		Secure:   h.SecureCookies,
+		Path:     "/",
+		SameSite: http.SameSiteStrictMode,
+	})
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequireCSRF(t *testing.T) {
+	h := &AuthHandler{}
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	token := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name    string
+		refresh bool   // Sends the refresh cookie
+		cookie  string // csrf_token cookie
+		header  string // X-CSRF-Token header
+		want    int
+	}{
+		{"No Refresh Cookie", false, "", "", http.StatusNoContent},
+		{"Matching Token", true, token, token, http.StatusNoContent},
+		{"Missing Header", true, token, "", http.StatusForbidden},
+		{"Missing Cookie", true, "", token, http.StatusForbidden},
+		{"Mismatch", true, token, strings.Repeat("cd", 32), http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/refresh", http.NoBody)
+			if tc.refresh {
+				req.AddCookie(&http.Cookie{Name: refreshCookieName, Value: "refresh"})
+			}
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: tc.cookie})
+			}
+			if tc.header != "" {
+				req.Header.Set(csrfHeaderName, tc.header)
+			}
+			rr := httptest.NewRecorder()
+			h.RequireCSRF(next).ServeHTTP(rr, req)
+
+			if rr.Code != tc.want {
+				t.Errorf("Expected %d, got %d", tc.want, rr.Code)
+			}
+		})
+	}
+}
+
+func TestCSRFToken(t *testing.T) {
+	h := &AuthHandler{SecureCookies: true}
+
+	get := func(cookie string) (string, *http.Cookie) {
+		req := httptest.NewRequest("GET", "/csrf", http.NoBody)
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: cookie})
+		}
+		rr := httptest.NewRecorder()
+		h.CSRFToken(rr, req)
+
+		cookies := rr.Result().Cookies()
+		if rr.Code != http.StatusOK || len(cookies) != 1 {
+			t.Fatalf("Expected 200 with a cookie, got %d: %v", rr.Code, cookies)
+		}
+		return cookies[0].Value, cookies[0]
+	}
+
+	// 1. A new token, in a Secure cookie readable by the web app
+	token, c := get("")
+	if len(token) != 64 || !c.Secure || c.HttpOnly {
+		t.Errorf("Unexpected cookie: %+v", c)
+	}
+
+	// 2. An existing token is kept, so other tabs keep working
+	if again, _ := get(token); again != token {
+		t.Errorf("Expected token %s to be kept, got %s", token, again)
+	}
+
+	// 3. A malformed one is replaced
+	if replaced, _ := get("not-a-token"); replaced == "not-a-token" {
+		t.Error("Expected a malformed token to be replaced")
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/mail"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+const (
+	// verificationResendLimit is how many verification emails a user may request per window.
+	verificationResendLimit = 3
+	// verificationResendWindow is the window of verificationResendLimit.
+	verificationResendWindow = time.Hour
+)
+
+// VerifyEmailInput defines the payload for verifying an email address.
+type VerifyEmailInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// VerifyEmail marks the email address of a user as verified.
+// @Summary Verify email
+// @Description Consumes the token from the verification link sent on registration.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body VerifyEmailInput true "Verification token"
+// @Success 200 {object} response.Response{data=map[string]string} "Email verified"
+// @Failure 400 {object} response.Response "Invalid or expired token"
+// @Router /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var input VerifyEmailInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	claims, err := auth.ParseChallengeToken(input.Token, auth.PurposeVerifyEmail, h.Keys)
+	if err != nil || claims.Email == "" {
+		response.Error(w, http.StatusBadRequest, "Invalid or expired token")
+		return
+	}
+
+	if err := h.UserRepo.MarkEmailVerified(r.Context(), claims.UserID, claims.Email); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			// The account is gone or its email changed since the link was sent
+			response.Error(w, http.StatusBadRequest, "Invalid or expired token")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{"message": "Email verified"})
+}
+
+// ResendVerification emails a new verification link to the current user.
+// @Summary Resend verification email
+// @Description Sends a new verification link. Limited to a few emails per hour.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=map[string]string} "Email sent"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 409 {object} response.Response "Already verified"
+// @Failure 429 {object} response.Response "Too many requests"
+// @Router /auth/verify-email/resend [post]
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	user, err := h.UserRepo.GetUserByID(r.Context(), claims.UserID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to load user")
+		return
+	}
+	if user.EmailVerified {
+		response.Error(w, http.StatusConflict, "Email already verified")
+		return
+	}
+
+	if ok, retryAfter := h.resendLimiter.Allow(user.ID); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+		response.Error(w, http.StatusTooManyRequests, "Too many requests. Try again later.")
+		return
+	}
+
+	if err := h.sendVerificationEmail(r.Context(), user.ID, user.Email, user.FirstName); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to send email")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{"message": "Verification email sent"})
+}
+
+// sendVerificationEmail emails a signed verification link for email to the user.
+func (h *AuthHandler) sendVerificationEmail(ctx context.Context, userID, email, firstName string) error {
+	token, err := auth.SignPurposeToken(
+		auth.Claims{UserID: userID, Email: email},
+		auth.PurposeVerifyEmail,
+		auth.EmailVerificationTokenDuration,
+		h.Keys,
+	)
+	if err != nil {
+		return err
+	}
+
+	link := h.AppURL + "/verify-email?token=" + url.QueryEscape(token)
+	return h.Mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm your email address by opening this link:\n\n%s\n\nThe link expires in %d hours.\n",
+			firstName, link, int(auth.EmailVerificationTokenDuration.Hours()),
+		),
+	})
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/off-by-2/sal/internal/auth"
+)
+
+// verificationToken extracts the token from the last verification email sent to email.
+func verificationToken(t *testing.T, handler *AuthHandler, email string) string {
+	t.Helper()
+
+	msg, ok := handler.Mailer.(*recordingMailer).last(email)
+	if !ok {
+		t.Fatalf("No email sent to %s", email)
+	}
+	i := strings.Index(msg.Body, "http://app.test/verify-email?")
+	if i < 0 {
+		t.Fatalf("No verification link in %q", msg.Body)
+	}
+	link, err := url.Parse(strings.Fields(msg.Body[i:])[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link.Query().Get("token")
+}
+
+// TestVerifyEmailIntegration verifies the link sent on registration.
+func TestVerifyEmailIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	handler := newTestAuthHandler(db)
+	email := registerTestUser(t, handler, "verify")
+
+	token := verificationToken(t, handler, email)
+
+	if code, _ := postWithBearer(t, handler.VerifyEmail, "", map[string]string{"token": "garbage"}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid token, got %d", code)
+	}
+
+	code, resp := postWithBearer(t, handler.VerifyEmail, "", map[string]string{"token": token})
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp.Data)
+	}
+
+	user, err := handler.UserRepo.GetUserByEmail(context.Background(), email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.EmailVerified {
+		t.Error("Expected email to be verified")
+	}
+
+	// Verified users cannot request another link
+	access := loginTestUser(t, handler, email).Data["access_token"].(string)
+	resend := auth.Authenticate(handler.Verifier)(http.HandlerFunc(handler.ResendVerification))
+	if code, _ := postWithBearer(t, resend.ServeHTTP, access, nil); code != http.StatusConflict {
+		t.Errorf("Expected 409 for verified user, got %d", code)
+	}
+}
+
+// TestResendVerification_RateLimited verifies that resending is limited per user.
+func TestResendVerification_RateLimited(t *testing.T) {
+	db := setupTestDB(t)
+	handler := newTestAuthHandler(db)
+	email := registerTestUser(t, handler, "verify-resend")
+
+	access := loginTestUser(t, handler, email).Data["access_token"].(string)
+	resend := auth.Authenticate(handler.Verifier)(http.HandlerFunc(handler.ResendVerification))
+
+	for i := 0; i < verificationResendLimit; i++ {
+		if code, resp := postWithBearer(t, resend.ServeHTTP, access, nil); code != http.StatusOK {
+			t.Fatalf("Resend %d: expected 200, got %d: %v", i+1, code, resp.Data)
+		}
+	}
+	if code, _ := postWithBearer(t, resend.ServeHTTP, access, nil); code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 after %d resends, got %d", verificationResendLimit, code)
+	}
+}
@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/config"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// ImpersonationHandler lets platform admins act as another user, e.g. to see what a clinician sees.
+// Every impersonation is time-boxed, cannot destroy data, and is recorded in the activity log.
+type ImpersonationHandler struct {
+	UserRepo  *repository.UserRepository
+	StaffRepo *repository.StaffRepository
+	Sessions  *repository.SessionRepository
+	Activity  *repository.ActivityLogRepository
+	Keys      *auth.KeyRing
+	Duration  time.Duration // Lifetime of an impersonation token
+	Validator *validator.Validate
+}
+
+// NewImpersonationHandler creates a new ImpersonationHandler.
+func NewImpersonationHandler(
+	userEq *repository.UserRepository,
+	staffEq *repository.StaffRepository,
+	sessionEq *repository.SessionRepository,
+	activityEq *repository.ActivityLogRepository,
+	keys *auth.KeyRing,
+	cfg *config.Config,
+) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		UserRepo:  userEq,
+		StaffRepo: staffEq,
+		Sessions:  sessionEq,
+		Activity:  activityEq,
+		Keys:      keys,
+		Duration:  cfg.ImpersonationDuration,
+		Validator: validator.New(),
+	}
+}
+
+// StartImpersonationInput defines the payload for starting an impersonation.
+type StartImpersonationInput struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	OrgID  string `json:"org_id" validate:"omitempty,uuid"` // Optional, defaults to the user's oldest membership
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// Start issues a token acting as another user.
+// @Summary Start impersonation
+// @Description Platform admins only. Returns a non-refreshable access token for the user, carrying the admin in its "act" claim.
+// @Description Destructive actions are refused with that token, and every write made with it is recorded in the activity log.
+// @Tags impersonation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body StartImpersonationInput true "User to impersonate and why"
+// @Success 200 {object} response.Response{data=map[string]interface{}} "Impersonation token"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Not a platform admin, or user cannot be impersonated"
+// @Failure 404 {object} response.Response "User not found"
+// @Router /impersonation [post]
+func (h *ImpersonationHandler) Start(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	var input StartImpersonationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	// 1. Only platform admins, as themselves
+	admin, err := h.UserRepo.GetUserByID(r.Context(), claims.UserID)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		response.Error(w, http.StatusInternalServerError, "Failed to load user")
+		return
+	}
+	if err != nil || !admin.IsActive || !admin.IsPlatformAdmin {
+		response.Error(w, http.StatusForbidden, "Platform admin access required")
+		return
+	}
+
+	// 2. Target
+	target, err := h.UserRepo.GetUserByID(r.Context(), input.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, "User not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to load user")
+		return
+	}
+	if target.ID == admin.ID || target.IsPlatformAdmin {
+		response.Error(w, http.StatusForbidden, "This user cannot be impersonated")
+		return
+	}
+	if !target.IsActive {
+		response.Error(w, http.StatusForbidden, "Account is inactive")
+		return
+	}
+
+	memberships, err := h.StaffRepo.ListMembershipsByUser(r.Context(), target.ID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to load memberships")
+		return
+	}
+	var orgID, role string
+	if input.OrgID != "" {
+		m := findMembership(memberships, input.OrgID)
+		if m == nil {
+			response.Error(w, http.StatusForbidden, "Not an active member of this organization")
+			return
+		}
+		orgID, role = m.OrganizationID, m.Role
+	} else if len(memberships) > 0 {
+		orgID, role = memberships[0].OrganizationID, memberships[0].Role
+	}
+
+	// 3. Session of its own, so it can be ended without touching the user's or the admin's sessions
+	client := sessionClient(r)
+	session := &repository.Session{
+		UserID:         target.ID,
+		ImpersonatorID: &admin.ID,
+		ExpiresAt:      time.Now().Add(h.Duration),
+	}
+	if orgID != "" {
+		session.OrganizationID = &orgID
+	}
+	if client.UserAgent != "" {
+		session.UserAgent = &client.UserAgent
+	}
+	if client.IPAddress != "" {
+		session.IPAddress = &client.IPAddress
+	}
+	if err := h.Sessions.CreateImpersonationSession(r.Context(), session); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+
+	accessToken, err := auth.SignImpersonationToken(auth.Claims{
+		UserID:    target.ID,
+		OrgID:     orgID,
+		Role:      role,
+		SessionID: session.ID,
+	}, admin.ID, h.Duration, h.Keys)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Token generation failed")
+		return
+	}
+
+	// 4. Audit; no trace, no impersonation
+	err = h.record(r, session, repository.ActionImpersonationStart, "Impersonation started", map[string]interface{}{
+		"reason":     input.Reason,
+		"session_id": session.ID,
+		"expires_at": session.ExpiresAt,
+	})
+	if err != nil {
+		_ = h.Sessions.RevokeSession(context.WithoutCancel(r.Context()), session.ID, "audit_failed")
+		response.Error(w, http.StatusInternalServerError, "Failed to record impersonation")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":    accessToken,
+		"expires_at":      session.ExpiresAt,
+		"organization_id": orgID,
+		"session_id":      session.ID,
+	})
+}
+
+// Stop ends the impersonation of the presented token.
+// @Summary Stop impersonation
+// @Description Revokes the impersonation token presented as bearer token. The admin's own session is not affected.
+// @Tags impersonation
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=map[string]string} "Impersonation ended"
+// @Failure 400 {object} response.Response "Not an impersonation token"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Router /impersonation/stop [post]
+func (h *ImpersonationHandler) Stop(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+	if !claims.Impersonated() {
+		response.Error(w, http.StatusBadRequest, "Not impersonating")
+		return
+	}
+
+	if err := h.Sessions.RevokeSession(r.Context(), claims.SessionID, "impersonation_ended"); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	session := impersonationSession(claims)
+	if err := h.record(r, session, repository.ActionImpersonationStop, "Impersonation ended", map[string]interface{}{
+		"session_id": claims.SessionID,
+	}); err != nil {
+		log.Printf("Failed to record end of impersonation %s: %v", claims.SessionID, err)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{"message": "Impersonation ended"})
+}
+
+// AuditWrites is a middleware recording every write made with an impersonation token, whatever its outcome.
+// It must run after auth.Authenticate.
+func (h *ImpersonationHandler) AuditWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := auth.ClaimsFromContext(r.Context())
+		if !ok || !claims.Impersonated() || isReadOnlyMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		err := h.record(r, impersonationSession(claims), repository.ActionImpersonationWrite, r.Method+" "+r.URL.Path, map[string]interface{}{
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"status":     status,
+			"session_id": claims.SessionID,
+		})
+		if err != nil {
+			log.Printf("Failed to record impersonated %s %s: %v", r.Method, r.URL.Path, err)
+		}
+	})
+}
+
+// record writes an impersonation entry: the admin is the actor, the impersonated user the entity.
+func (h *ImpersonationHandler) record(r *http.Request, s *repository.Session, action, description string, changes map[string]interface{}) error {
+	entityType := "user"
+	client := sessionClient(r)
+	entry := &repository.ActivityEntry{
+		OrganizationID: s.OrganizationID,
+		UserID:         s.ImpersonatorID,
+		Action:         action,
+		EntityType:     &entityType,
+		EntityID:       &s.UserID,
+		Description:    description,
+		Changes:        changes,
+	}
+	if client.UserAgent != "" {
+		entry.UserAgent = &client.UserAgent
+	}
+	if client.IPAddress != "" {
+		entry.IPAddress = &client.IPAddress
+	}
+	// The response may already be written: don't lose the entry to a client hanging up
+	return h.Activity.Record(context.WithoutCancel(r.Context()), entry)
+}
+
+// impersonationSession describes the session of an impersonation token from its claims.
+func impersonationSession(claims *auth.Claims) *repository.Session {
+	s := &repository.Session{ID: claims.SessionID, UserID: claims.UserID, ImpersonatorID: &claims.Actor.UserID}
+	if claims.OrgID != "" {
+		s.OrganizationID = &claims.OrgID
+	}
+	return s
+}
+
+// isReadOnlyMethod reports whether an HTTP method cannot change state.
+func isReadOnlyMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/config"
+	"github.com/off-by-2/sal/internal/repository"
+)
+
+// authMiddleware puts fn behind auth.Authenticate.
+func authMiddleware(h *AuthHandler, fn http.HandlerFunc) http.HandlerFunc {
+	return auth.Authenticate(h.Verifier)(fn).ServeHTTP
+}
+
+func TestImpersonationIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	authHandler := newTestAuthHandler(db)
+	h := NewImpersonationHandler(authHandler.UserRepo, authHandler.StaffRepo, authHandler.Sessions,
+		repository.NewActivityLogRepository(db), authHandler.Keys, &config.Config{ImpersonationDuration: 30 * time.Minute})
+
+	supportEmail := registerTestUser(t, authHandler, "support")
+	support := loginTestUser(t, authHandler, supportEmail).Data["access_token"].(string)
+	target, err := authHandler.UserRepo.GetUserByEmail(ctx, registerTestUser(t, authHandler, "impersonated"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := func() (int, APIResponse) {
+		return postWithBearer(t, authMiddleware(authHandler, h.Start), support, map[string]string{
+			"user_id": target.ID,
+			"reason":  "Ticket 123: notes not showing",
+		})
+	}
+
+	// 1. Only platform admins
+	if code, _ := start(); code != http.StatusForbidden {
+		t.Fatalf("Expected 403 for a regular user, got %d", code)
+	}
+	if _, err := db.Pool.Exec(ctx, `UPDATE users SET is_platform_admin = true WHERE email = $1`, supportEmail); err != nil {
+		t.Fatal(err)
+	}
+
+	code, resp := start()
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp.Error)
+	}
+	token := resp.Data["access_token"].(string)
+	claims, err := authHandler.Verifier.Verify(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != target.ID || !claims.Impersonated() {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	// 2. Writes are audited, destructive ones refused
+	audited := h.AuditWrites(auth.DenyImpersonation(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	if code, _ := postWithBearer(t, authMiddleware(authHandler, audited.ServeHTTP), token, nil); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a denied route, got %d", code)
+	}
+
+	// 3. Stop
+	if code, _ := postWithBearer(t, authMiddleware(authHandler, h.Stop), token, nil); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if _, err := authHandler.Verifier.Verify(ctx, token); err == nil {
+		t.Error("Expected the impersonation token to be revoked")
+	}
+
+	entries, err := h.Activity.ListEntityActivity(ctx, "user", target.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, e := range entries {
+		actions = append(actions, e.Action)
+	}
+	want := []string{repository.ActionImpersonationStop, repository.ActionImpersonationWrite, repository.ActionImpersonationStart}
+	if len(actions) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, actions)
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, actions)
+			break
+		}
+	}
+}
+
+func TestImpersonationStop_NotImpersonating(t *testing.T) {
+	h := &ImpersonationHandler{}
+
+	req := httptest.NewRequest("POST", "/impersonation/stop", nil)
+	rr := httptest.NewRecorder()
+	h.Stop(rr, req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UserID: "user-1"})))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,413 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/authz"
+	"github.com/off-by-2/sal/internal/config"
+	"github.com/off-by-2/sal/internal/mail"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// codeAccountRequired is the error code of invitations accepted without password by someone without an account.
+const codeAccountRequired = "account_required"
+
+// InvitationHandler handles staff invitations: admins invite by email, the invitee accepts with the emailed link.
+type InvitationHandler struct {
+	Invitations *repository.InvitationRepository
+	Mailer      mail.Mailer
+	Hasher      auth.PasswordHasher
+	Passwords   auth.PasswordPolicy
+	AppURL      string        // Base URL of the web app, for links in emails
+	Duration    time.Duration // Lifetime of an invitation link
+	Validator   *validator.Validate
+}
+
+// NewInvitationHandler creates a new InvitationHandler.
+// New accounts follow the password rules of the AuthHandler.
+func NewInvitationHandler(
+	invitationEq *repository.InvitationRepository,
+	mailer mail.Mailer,
+	hasher auth.PasswordHasher,
+	passwords auth.PasswordPolicy,
+	cfg *config.Config,
+) *InvitationHandler {
+	v := validator.New()
+	if err := passwords.RegisterValidators(v); err != nil {
+		panic(err)
+	}
+
+	return &InvitationHandler{
+		Invitations: invitationEq,
+		Mailer:      mailer,
+		Hasher:      hasher,
+		Passwords:   passwords,
+		AppURL:      cfg.AppURL,
+		Duration:    auth.InvitationTokenDuration,
+		Validator:   v,
+	}
+}
+
+// CreateInvitationInput defines the payload for inviting a staff member.
+type CreateInvitationInput struct {
+	Email     string  `json:"email" validate:"required,email,max=255"`
+	FirstName string  `json:"first_name" validate:"max=100"`
+	LastName  string  `json:"last_name" validate:"max=100"`
+	Role      string  `json:"role" validate:"omitempty,oneof=admin staff"`                // Defaults to staff
+	Preset    string  `json:"preset" validate:"omitempty,oneof=staff supervisor manager"` // Defaults to staff
+	GroupID   *string `json:"group_id" validate:"omitempty,uuid"`                         // Optional initial group
+}
+
+// AcceptInvitationInput defines the payload for accepting an invitation.
+// Password and names are only used when the invited email has no account yet.
+type AcceptInvitationInput struct {
+	Token     string `json:"token" validate:"required"`
+	Password  string `json:"password" validate:"omitempty,password"`
+	FirstName string `json:"first_name" validate:"max=100"`
+	LastName  string `json:"last_name" validate:"max=100"`
+}
+
+// Create invites someone to the caller's organization by email.
+// Requires the "staff.invite" permission.
+// @Summary Invite staff
+// @Description Emails a link to join the organization with the given role and permission preset (staff, supervisor or manager),
+// @Description and optionally an initial group. Only admins may invite admins, and others may only grant permissions they hold.
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body CreateInvitationInput true "Invitee"
+// @Success 201 {object} response.Response{data=map[string]interface{}} "Invitation"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 404 {object} response.Response "Group not found"
+// @Failure 409 {object} response.Response "Already a member, already invited, or no staff seat left (code quota_exceeded)"
+// @Router /invitations [post]
+func (h *InvitationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	var input CreateInvitationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	// 1. Nobody grants more than they hold
+	if input.Role == "" {
+		input.Role = "staff"
+	}
+	if input.Preset == "" {
+		input.Preset = repository.PresetStaff
+	}
+	permissions, _ := repository.PermissionPreset(input.Preset)
+	if input.Role == authz.RoleAdmin {
+		permissions = repository.Permissions{}
+	}
+
+	inviter, ok := authz.StaffFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusForbidden, "Not an active member of this organization")
+		return
+	}
+	if inviter.Role != authz.RoleAdmin && (input.Role == authz.RoleAdmin || !inviter.Permissions.Covers(permissions)) {
+		response.Error(w, http.StatusForbidden, "You cannot grant more permissions than you hold")
+		return
+	}
+
+	// 2. Store
+	token, err := auth.NewInvitationToken()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Token generation failed")
+		return
+	}
+	inv := &repository.Invitation{
+		OrganizationID: claims.OrgID,
+		GroupID:        input.GroupID,
+		Email:          normalizeEmail(input.Email),
+		FirstName:      optionalString(input.FirstName),
+		LastName:       optionalString(input.LastName),
+		Role:           input.Role,
+		Permissions:    permissions,
+		InvitedBy:      claims.UserID,
+		ExpiresAt:      time.Now().Add(h.Duration),
+	}
+	if err := h.Invitations.CreateInvitation(r.Context(), inv, auth.HashRefreshToken(token)); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrAlreadyMember):
+			response.Error(w, http.StatusConflict, "Already a member of this organization")
+		case errors.Is(err, repository.ErrInvitationPending):
+			response.Error(w, http.StatusConflict, "An invitation is already pending for this email")
+		case errors.Is(err, repository.ErrGroupNotFound):
+			response.Error(w, http.StatusNotFound, "Group not found")
+		case errors.Is(err, repository.ErrQuotaExceeded):
+			quotaExceeded(w, err)
+		default:
+			response.Error(w, http.StatusInternalServerError, "Failed to create invitation")
+		}
+		return
+	}
+
+	// 3. Send; the invitation can be resent if this fails
+	sent := h.send(r.Context(), inv, token)
+
+	response.JSON(w, http.StatusCreated, map[string]interface{}{
+		"invitation": inv,
+		"email_sent": sent,
+	})
+}
+
+// List lists the invitations of the caller's organization.
+// Requires the "staff.invite" permission.
+// @Summary List invitations
+// @Description Returns the invitations of the organization, newest first. Pending invitations past their expiry are reported as expired.
+// @Tags invitations
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by status (pending, accepted, expired, revoked)"
+// @Success 200 {object} response.Response{data=map[string][]repository.Invitation} "Invitations"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Router /invitations [get]
+func (h *InvitationHandler) List(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	status := r.URL.Query().Get("status")
+	if h.Validator.Var(status, "omitempty,oneof=pending accepted expired revoked") != nil {
+		response.Error(w, http.StatusBadRequest, "status must be pending, accepted, expired or revoked")
+		return
+	}
+
+	invitations, err := h.Invitations.ListOrgInvitations(r.Context(), claims.OrgID, status)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to list invitations")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{"invitations": invitations})
+}
+
+// Resend emails a pending or expired invitation again with a new link.
+// Requires the "staff.invite" permission.
+// @Summary Resend invitation
+// @Description Replaces the link of the invitation (the previous one stops working), extends it and emails it again.
+// @Tags invitations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} response.Response{data=map[string]interface{}} "Invitation"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 404 {object} response.Response "Not Found"
+// @Failure 409 {object} response.Response "Already accepted or revoked"
+// @Router /invitations/{id}/resend [post]
+func (h *InvitationHandler) Resend(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	id := chi.URLParam(r, "id")
+	if h.Validator.Var(id, "uuid") != nil {
+		response.Error(w, http.StatusNotFound, "Invitation not found")
+		return
+	}
+
+	token, err := auth.NewInvitationToken()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Token generation failed")
+		return
+	}
+	inv, err := h.Invitations.ResendInvitation(r.Context(), claims.OrgID, id, auth.HashRefreshToken(token), time.Now().Add(h.Duration))
+	if err != nil {
+		h.updateError(w, err, "Failed to resend invitation")
+		return
+	}
+
+	sent := h.send(r.Context(), inv, token)
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"invitation": inv,
+		"email_sent": sent,
+	})
+}
+
+// Revoke revokes a pending or expired invitation; its link stops working.
+// Requires the "staff.invite" permission.
+// @Summary Revoke invitation
+// @Tags invitations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} response.Response{data=map[string]repository.Invitation} "Invitation revoked"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 404 {object} response.Response "Not Found"
+// @Failure 409 {object} response.Response "Already accepted or revoked"
+// @Router /invitations/{id} [delete]
+func (h *InvitationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	id := chi.URLParam(r, "id")
+	if h.Validator.Var(id, "uuid") != nil {
+		response.Error(w, http.StatusNotFound, "Invitation not found")
+		return
+	}
+
+	inv, err := h.Invitations.RevokeInvitation(r.Context(), claims.OrgID, id)
+	if err != nil {
+		h.updateError(w, err, "Failed to revoke invitation")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{"invitation": inv})
+}
+
+// Accept joins the organization of an invitation.
+// @Summary Accept invitation
+// @Description Adds the account of the invited email to the organization, with the invited role, permissions and group.
+// @Description Without an account, one is created with the given password and names; the email counts as verified.
+// @Description Without an account and without password, the response is 422 with code account_required.
+// @Description When the organization has no staff seat left, the response is 409 with code quota_exceeded.
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param input body AcceptInvitationInput true "Invitation token, and the new account if needed"
+// @Success 200 {object} response.Response{data=map[string]interface{}} "Membership"
+// @Failure 400 {object} response.Response "Invalid or expired invitation"
+// @Failure 409 {object} response.Response "Already a member, or no staff seat left"
+// @Failure 422 {object} response.Response "Validation Error, or account required"
+// @Router /invitations/accept [post]
+func (h *InvitationHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	var input AcceptInvitationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	tokenHash := auth.HashRefreshToken(input.Token)
+
+	// 1. Check the token
+	inv, err := h.Invitations.GetInvitationByToken(r.Context(), tokenHash)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvitationInvalid) {
+			response.Error(w, http.StatusBadRequest, "Invalid or expired invitation")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to accept invitation")
+		return
+	}
+
+	// 2. The account to create, should the email have none
+	var newUser *repository.InvitedUser
+	if input.Password != "" {
+		if h.Passwords.RejectPersonal && h.Passwords.ContainsPersonal(input.Password, inv.Email, inv.OrganizationName) {
+			response.FieldError(w, "Password", auth.TagPersonalPassword)
+			return
+		}
+		hashedPW, err := h.Hasher.Hash(input.Password)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to process password")
+			return
+		}
+		newUser = &repository.InvitedUser{
+			FirstName:    firstNonEmpty(input.FirstName, inv.FirstName),
+			LastName:     firstNonEmpty(input.LastName, inv.LastName),
+			PasswordHash: hashedPW,
+		}
+	}
+
+	// 3. Join
+	staff, err := h.Invitations.AcceptInvitation(r.Context(), tokenHash, newUser)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvitationInvalid):
+			response.Error(w, http.StatusBadRequest, "Invalid or expired invitation")
+		case errors.Is(err, repository.ErrAccountRequired):
+			response.ErrorCode(w, http.StatusUnprocessableEntity, codeAccountRequired, "Choose a password to create your account")
+		case errors.Is(err, repository.ErrAlreadyMember):
+			response.Error(w, http.StatusConflict, "Already a member of this organization")
+		case errors.Is(err, repository.ErrQuotaExceeded):
+			quotaExceeded(w, err)
+		default:
+			response.Error(w, http.StatusInternalServerError, "Failed to accept invitation")
+		}
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"staff":             staff,
+		"organization_name": inv.OrganizationName,
+		"email":             inv.Email,
+	})
+}
+
+// updateError responds to a failed resend or revocation.
+func (h *InvitationHandler) updateError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrInvitationNotFound):
+		response.Error(w, http.StatusNotFound, "Invitation not found")
+	case errors.Is(err, repository.ErrInvitationClosed):
+		response.Error(w, http.StatusConflict, "Invitation already accepted or revoked")
+	default:
+		response.Error(w, http.StatusInternalServerError, message)
+	}
+}
+
+// send emails the invitation link and reports whether it was sent.
+func (h *InvitationHandler) send(ctx context.Context, inv *repository.Invitation, token string) bool {
+	link := h.AppURL + "/join?token=" + url.QueryEscape(token)
+	greeting := "Hi"
+	if inv.FirstName != nil {
+		greeting += " " + *inv.FirstName
+	}
+
+	err := h.Mailer.Send(context.WithoutCancel(ctx), mail.Message{
+		To:      inv.Email,
+		Subject: "You are invited to join " + inv.OrganizationName,
+		Body: fmt.Sprintf(
+			"%s,\n\nYou have been invited to join %s. To accept, open this link:\n\n%s\n\n"+
+				"The link expires in %d days. If you did not expect this, you can ignore this email.\n",
+			greeting, inv.OrganizationName, link, int(h.Duration.Hours()/24),
+		),
+	})
+	if err != nil {
+		log.Printf("Failed to send invitation %s: %v", inv.ID, err)
+		return false
+	}
+	return true
+}
+
+// optionalString returns nil for an empty string.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// firstNonEmpty returns s, or fallback if s is empty.
+func firstNonEmpty(s string, fallback *string) string {
+	if s == "" && fallback != nil {
+		return *fallback
+	}
+	return s
+}
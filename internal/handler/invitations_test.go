@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/authz"
+	"github.com/off-by-2/sal/internal/config"
+	"github.com/off-by-2/sal/internal/repository"
+)
+
+// invitationToken extracts the token from the last invitation sent to email.
+func invitationToken(t *testing.T, mailer *recordingMailer, email string) string {
+	t.Helper()
+
+	msg, ok := mailer.last(email)
+	if !ok || !strings.HasPrefix(msg.Subject, "You are invited") {
+		t.Fatalf("No invitation sent to %s", email)
+	}
+	i := strings.Index(msg.Body, "http://app.test/join?")
+	if i < 0 {
+		t.Fatalf("No invitation link in %q", msg.Body)
+	}
+	link, err := url.Parse(strings.Fields(msg.Body[i:])[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link.Query().Get("token")
+}
+
+// staticLoader serves the same staff row for every membership lookup.
+type staticLoader struct{ staff *repository.Staff }
+
+func (l staticLoader) GetStaffByUserAndOrg(_ context.Context, _, _ string) (*repository.Staff, error) {
+	return l.staff, nil
+}
+
+// postWithClaims posts body to fn as the caller described by claims.
+func postWithClaims(t *testing.T, fn http.HandlerFunc, claims *auth.Claims, body interface{}) (int, APIResponse) {
+	t.Helper()
+
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/", bytes.NewBuffer(raw))
+	rr := httptest.NewRecorder()
+	fn(rr, req.WithContext(auth.WithClaims(req.Context(), claims)))
+
+	var resp APIResponse
+	_ = json.NewDecoder(rr.Body).Decode(&resp)
+	return rr.Code, resp
+}
+
+func TestInvitationIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	authHandler := newTestAuthHandler(db)
+	mailer := authHandler.Mailer.(*recordingMailer)
+	h := NewInvitationHandler(repository.NewInvitationRepository(db), mailer, authHandler.Hasher, authHandler.Passwords,
+		&config.Config{AppURL: "http://app.test"})
+	az := authz.NewAuthorizer(authHandler.StaffRepo, time.Minute, 0)
+	invite := authMiddleware(authHandler, az.RequirePermission(repository.CapStaffInvite)(http.HandlerFunc(h.Create)).ServeHTTP)
+
+	admin := loginTestUser(t, authHandler, registerTestUser(t, authHandler, "inviter")).Data["access_token"].(string)
+	newcomer := fmt.Sprintf("newcomer-%d@example.com", time.Now().UnixNano())
+
+	// 1. Invite; a second pending invitation is refused
+	code, resp := postWithBearer(t, invite, admin, map[string]string{"email": newcomer, "first_name": "New", "preset": "supervisor"})
+	if code != http.StatusCreated || resp.Data["email_sent"] != true {
+		t.Fatalf("Expected 201 with the email sent, got %d: %v", code, resp.Data)
+	}
+	if code, _ := postWithBearer(t, invite, admin, map[string]string{"email": newcomer}); code != http.StatusConflict {
+		t.Errorf("Expected 409 for a second invitation, got %d", code)
+	}
+	token := invitationToken(t, mailer, newcomer)
+
+	// 2. Without an account, a password is needed
+	code, resp = postWithBearer(t, h.Accept, "", map[string]string{"token": token})
+	if code != http.StatusUnprocessableEntity || resp.Data["code"] != codeAccountRequired {
+		t.Fatalf("Expected 422 account_required, got %d: %v", code, resp.Data)
+	}
+
+	// 3. Accept with a new account, which can log in with the invited permissions
+	code, resp = postWithBearer(t, h.Accept, "", map[string]string{"token": token, "password": "TestPass123!", "last_name": "Comer"})
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp.Data)
+	}
+	login := loginTestUser(t, authHandler, newcomer)
+	claims, err := authHandler.Verifier.Verify(ctx, login.Data["access_token"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	staff, err := authHandler.StaffRepo.GetStaffByUserAndOrg(ctx, claims.UserID, claims.OrgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staff.Role != "staff" || !staff.Permissions.Allows(repository.CapNotesUpdateAny) || staff.Permissions.Allows(repository.CapStaffInvite) {
+		t.Errorf("Unexpected membership: %+v", staff)
+	}
+
+	// 4. The link is spent
+	if code, _ := postWithBearer(t, h.Accept, "", map[string]string{"token": token}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a spent invitation, got %d", code)
+	}
+
+	// 5. An existing account joins with the link alone
+	existing := registerTestUser(t, authHandler, "existing")
+	if code, _ := postWithBearer(t, invite, admin, map[string]string{"email": existing}); code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", code)
+	}
+	if code, resp := postWithBearer(t, h.Accept, "", map[string]string{"token": invitationToken(t, mailer, existing)}); code != http.StatusOK {
+		t.Fatalf("Expected 200 for an existing account, got %d: %v", code, resp.Data)
+	}
+	if login := loginTestUser(t, authHandler, existing); len(login.Data["memberships"].([]interface{})) != 2 {
+		t.Errorf("Expected 2 memberships, got %v", login.Data["memberships"])
+	}
+
+	// 6. Revoked invitations cannot be accepted
+	revoked := fmt.Sprintf("revoked-%d@example.com", time.Now().UnixNano())
+	code, resp = postWithBearer(t, invite, admin, map[string]string{"email": revoked})
+	if code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", code)
+	}
+	id := resp.Data["invitation"].(map[string]interface{})["id"].(string)
+	if _, err := h.Invitations.RevokeInvitation(ctx, claims.OrgID, id); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := postWithBearer(t, h.Accept, "", map[string]string{"token": invitationToken(t, mailer, revoked), "password": "TestPass123!"}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a revoked invitation, got %d", code)
+	}
+	if _, err := h.Invitations.ResendInvitation(ctx, claims.OrgID, id, "x", time.Now().Add(time.Hour)); err != repository.ErrInvitationClosed {
+		t.Errorf("Expected ErrInvitationClosed, got %v", err)
+	}
+}
+
+func TestInvitationIntegration_StaffQuota(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	authHandler := newTestAuthHandler(db)
+	mailer := authHandler.Mailer.(*recordingMailer)
+	h := NewInvitationHandler(repository.NewInvitationRepository(db), mailer, authHandler.Hasher, authHandler.Passwords,
+		&config.Config{AppURL: "http://app.test"})
+	az := authz.NewAuthorizer(authHandler.StaffRepo, time.Minute, 0)
+	invite := authMiddleware(authHandler, az.RequirePermission(repository.CapStaffInvite)(http.HandlerFunc(h.Create)).ServeHTTP)
+
+	admin := loginTestUser(t, authHandler, registerTestUser(t, authHandler, "quota-inviter")).Data["access_token"].(string)
+	claims, err := authHandler.Verifier.Verify(ctx, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 1. Two invitations for the one seat left next to the admin
+	if _, err := db.Pool.Exec(ctx, `UPDATE organizations SET max_staff = 2 WHERE id = $1`, claims.OrgID); err != nil {
+		t.Fatal(err)
+	}
+	var tokens []string
+	for _, prefix := range []string{"first", "second"} {
+		email := fmt.Sprintf("%s-seat-%d@example.com", prefix, time.Now().UnixNano())
+		if code, resp := postWithBearer(t, invite, admin, map[string]string{"email": email}); code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d: %v", code, resp.Data)
+		}
+		tokens = append(tokens, invitationToken(t, mailer, email))
+	}
+
+	// 2. The first takes the seat, the second is refused
+	if code, resp := postWithBearer(t, h.Accept, "", map[string]string{"token": tokens[0], "password": "TestPass123!"}); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp.Data)
+	}
+	code, resp := postWithBearer(t, h.Accept, "", map[string]string{"token": tokens[1], "password": "TestPass123!"})
+	if code != http.StatusConflict || resp.Data["code"] != codeQuotaExceeded {
+		t.Errorf("Expected 409 %s, got %d: %v", codeQuotaExceeded, code, resp.Data)
+	}
+
+	// 3. A full organization can't invite
+	code, resp = postWithBearer(t, invite, admin, map[string]string{"email": fmt.Sprintf("third-seat-%d@example.com", time.Now().UnixNano())})
+	if code != http.StatusConflict || resp.Data["code"] != codeQuotaExceeded {
+		t.Errorf("Expected 409 %s, got %d: %v", codeQuotaExceeded, code, resp.Data)
+	}
+}
+
+func TestInvitationCreate_CannotEscalate(t *testing.T) {
+	nurse := &repository.Staff{Role: "staff", Permissions: repository.DefaultPermissions()}
+	nurse.Permissions.Staff.Invite = true
+	a := authz.NewAuthorizer(staticLoader{nurse}, time.Minute, 0)
+	h := NewInvitationHandler(nil, nil, auth.PasswordHasher{}, auth.PasswordPolicy{}, &config.Config{})
+	create := a.RequirePermission(repository.CapStaffInvite)(http.HandlerFunc(h.Create))
+
+	for _, body := range []map[string]string{
+		{"email": "a@example.com", "role": "admin"},
+		{"email": "a@example.com", "preset": "manager"},
+	} {
+		code, _ := postWithClaims(t, create.ServeHTTP, &auth.Claims{UserID: "nurse", OrgID: "org-1"}, body)
+		if code != http.StatusForbidden {
+			t.Errorf("Expected 403 for %v, got %d", body, code)
+		}
+	}
+}
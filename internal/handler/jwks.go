@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// jwksMaxAge is how long clients may cache the key set.
+// A new key must be published for longer than this before it becomes active.
+const jwksMaxAge = "300"
+
+// JWKS publishes the public keys that access tokens are signed with.
+// @Summary JSON Web Key Set
+// @Description Public keys (active and retired) for verifying access tokens, selected by the token's "kid" header.
+// @Description Served at the server root, not under /api/v1.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} auth.JWKSet "Key set"
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(w http.ResponseWriter, _ *http.Request) {
+	// Served bare (not wrapped in response.Response), as JWKS consumers expect
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age="+jwksMaxAge)
+	_ = json.NewEncoder(w).Encode(h.Keys.JWKS())
+}
@@ -0,0 +1,383 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// codeQuotaExceeded is the error code of creations refused because the organization reached a limit of its plan,
+// for clients to offer an upgrade.
+const codeQuotaExceeded = "quota_exceeded"
+
+// quotaNames names the quotas in error messages.
+var quotaNames = map[string]string{
+	repository.QuotaStaff:         "staff members",
+	repository.QuotaBeneficiaries: "beneficiaries",
+}
+
+// OrgHandler handles organization profile and settings requests, and the platform admins' suspensions.
+type OrgHandler struct {
+	Orgs      *repository.OrganizationRepository
+	UserRepo  *repository.UserRepository
+	Activity  *repository.ActivityLogRepository
+	Validator *validator.Validate
+}
+
+// NewOrgHandler creates a new OrgHandler.
+func NewOrgHandler(orgEq *repository.OrganizationRepository, userEq *repository.UserRepository, activityEq *repository.ActivityLogRepository) *OrgHandler {
+	return &OrgHandler{
+		Orgs:      orgEq,
+		UserRepo:  userEq,
+		Activity:  activityEq,
+		Validator: validator.New(),
+	}
+}
+
+// UpdateOrgInput defines the payload for updating an organization. Omitted fields are left unchanged.
+type UpdateOrgInput struct {
+	Name     *string              `json:"name" validate:"omitnil,min=2,max=255"`
+	Settings *UpdateSettingsInput `json:"settings"`
+}
+
+// UpdateSettingsInput is a partial organization settings document: only the keys present are changed,
+// and features are merged flag by flag.
+type UpdateSettingsInput struct {
+	Features             map[string]bool `json:"features" validate:"omitempty,dive,keys,oneof=offline_sync ai_transcription advanced_analytics,endkeys"`
+	Timezone             *string         `json:"timezone" validate:"omitnil,timezone"` // IANA name, e.g. "Europe/Paris"
+	DateFormat           *string         `json:"date_format" validate:"omitnil,oneof=MM/DD/YYYY DD/MM/YYYY YYYY-MM-DD"`
+	RequireTwoFactor     *bool           `json:"require_two_factor"`
+	RequireVerifiedEmail *bool           `json:"require_verified_email"`
+	AudioRetentionDays   *int            `json:"audio_retention_days" validate:"omitnil,min=0,max=36500"` // 0 keeps audio forever
+}
+
+// apply merges the input into settings.
+func (in *UpdateSettingsInput) apply(s *repository.OrgSettings) {
+	if len(in.Features) > 0 {
+		features := make(map[string]bool, len(s.Features)+len(in.Features))
+		for k, v := range s.Features {
+			features[k] = v
+		}
+		for k, v := range in.Features {
+			features[k] = v
+		}
+		s.Features = features
+	}
+	if in.Timezone != nil {
+		s.Timezone = *in.Timezone
+	}
+	if in.DateFormat != nil {
+		s.DateFormat = *in.DateFormat
+	}
+	if in.RequireTwoFactor != nil {
+		s.RequireTwoFactor = *in.RequireTwoFactor
+	}
+	if in.RequireVerifiedEmail != nil {
+		s.RequireVerifiedEmail = *in.RequireVerifiedEmail
+	}
+	if in.AudioRetentionDays != nil {
+		s.AudioRetentionDays = *in.AudioRetentionDays
+	}
+}
+
+// Get returns the caller's organization with its settings.
+// Requires membership of the organization.
+// @Summary Get organization
+// @Description Returns the organization's name, slug and settings. Only the caller's current organization can be read.
+// @Tags orgs
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} response.Response{data=map[string]repository.Organization} "Organization"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 404 {object} response.Response "Not Found"
+// @Router /orgs/{id} [get]
+func (h *OrgHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.orgID(w, r)
+	if !ok {
+		return
+	}
+
+	org, err := h.Orgs.GetOrg(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrgNotFound) {
+			response.Error(w, http.StatusNotFound, "Organization not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to load organization")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{"organization": org})
+}
+
+// Update renames the caller's organization and/or changes some of its settings.
+// Requires the admin role.
+// @Summary Update organization
+// @Description Partial update: omitted fields and settings keys keep their value. Renaming regenerates the slug.
+// @Description Settings are validated: timezone must be an IANA name, date_format one of MM/DD/YYYY, DD/MM/YYYY, YYYY-MM-DD,
+// @Description audio_retention_days non-negative (0 keeps audio forever).
+// @Tags orgs
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param input body UpdateOrgInput true "Name and settings to change"
+// @Success 200 {object} response.Response{data=map[string]repository.Organization} "Organization updated"
+// @Failure 400 {object} response.Response "Invalid request body"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 404 {object} response.Response "Not Found"
+// @Failure 422 {object} response.Response "Invalid settings"
+// @Router /orgs/{id} [patch]
+func (h *OrgHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.orgID(w, r)
+	if !ok {
+		return
+	}
+
+	// 1. Parse and validate
+	var input UpdateOrgInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	// 2. Merge into the current values under a row lock
+	org, err := h.Orgs.UpdateOrg(r.Context(), id, func(o *repository.Organization) {
+		if input.Name != nil {
+			o.Name = *input.Name
+		}
+		if input.Settings != nil {
+			input.Settings.apply(&o.Settings)
+		}
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrOrgNotFound) {
+			response.Error(w, http.StatusNotFound, "Organization not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to update organization")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{"organization": org})
+}
+
+// Usage returns the quotas of the caller's organization: current counts against limits.
+// Requires membership of the organization.
+// @Summary Get organization usage
+// @Description Counts active staff and beneficiaries against the organization's max_staff and max_beneficiaries.
+// @Description A null limit is unlimited. Creations beyond a limit are refused with 409 and code quota_exceeded.
+// @Tags orgs
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} response.Response{data=map[string]repository.OrgUsage} "Usage"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 404 {object} response.Response "Not Found"
+// @Router /orgs/{id}/usage [get]
+func (h *OrgHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.orgID(w, r)
+	if !ok {
+		return
+	}
+
+	usage, err := h.Orgs.GetOrgUsage(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrgNotFound) {
+			response.Error(w, http.StatusNotFound, "Organization not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to load usage")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{"usage": usage})
+}
+
+// SuspendOrgInput defines the payload for suspending an organization.
+type SuspendOrgInput struct {
+	Reason   string `json:"reason" validate:"required,max=500"`
+	ReadOnly bool   `json:"read_only"` // Let staff sign in and read (e.g. to export their data), but not write
+}
+
+// Suspend suspends an organization. Its staff can no longer sign in and its tokens and API keys are refused
+// with code organization_suspended, or in read-only mode only their writes are.
+// Requires a platform admin.
+// @Summary Suspend organization
+// @Description Takes effect on the next request of every session and API key of the organization. Suspending again changes reason and mode.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param input body SuspendOrgInput true "Reason and mode"
+// @Success 200 {object} response.Response{data=map[string]repository.Organization} "Organization suspended"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 404 {object} response.Response "Not Found"
+// @Router /admin/orgs/{id}/suspend [post]
+func (h *OrgHandler) Suspend(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.platformAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var input SuspendOrgInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if h.Validator.Var(id, "uuid") != nil {
+		response.Error(w, http.StatusNotFound, "Organization not found")
+		return
+	}
+
+	org, err := h.Orgs.SuspendOrg(r.Context(), id, input.Reason, input.ReadOnly)
+	if err != nil {
+		h.suspensionError(w, err)
+		return
+	}
+
+	if err := h.record(r, admin.ID, org, repository.ActionOrgSuspend, "Organization suspended", map[string]interface{}{
+		"reason":    input.Reason,
+		"read_only": input.ReadOnly,
+	}); err != nil {
+		log.Printf("Failed to record suspension of organization %s: %v", org.ID, err)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{"organization": org})
+}
+
+// Reinstate lifts the suspension of an organization.
+// Requires a platform admin.
+// @Summary Reinstate organization
+// @Description Lifts the suspension. Reinstating an active organization is a no-op.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} response.Response{data=map[string]repository.Organization} "Organization reinstated"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 404 {object} response.Response "Not Found"
+// @Router /admin/orgs/{id}/reinstate [post]
+func (h *OrgHandler) Reinstate(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.platformAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if h.Validator.Var(id, "uuid") != nil {
+		response.Error(w, http.StatusNotFound, "Organization not found")
+		return
+	}
+
+	org, err := h.Orgs.ReinstateOrg(r.Context(), id)
+	if err != nil {
+		h.suspensionError(w, err)
+		return
+	}
+
+	if err := h.record(r, admin.ID, org, repository.ActionOrgReinstate, "Organization reinstated", nil); err != nil {
+		log.Printf("Failed to record reinstatement of organization %s: %v", org.ID, err)
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{"organization": org})
+}
+
+// platformAdmin returns the caller if they are an active platform admin, and answers 403 otherwise.
+func (h *OrgHandler) platformAdmin(w http.ResponseWriter, r *http.Request) (*repository.User, bool) {
+	claims := auth.MustClaims(r.Context())
+
+	admin, err := h.UserRepo.GetUserByID(r.Context(), claims.UserID)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		response.Error(w, http.StatusInternalServerError, "Failed to load user")
+		return nil, false
+	}
+	if err != nil || !admin.IsActive || !admin.IsPlatformAdmin {
+		response.Error(w, http.StatusForbidden, "Platform admin access required")
+		return nil, false
+	}
+	return admin, true
+}
+
+// suspensionError answers a failed SuspendOrg or ReinstateOrg.
+func (h *OrgHandler) suspensionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, repository.ErrOrgNotFound) {
+		response.Error(w, http.StatusNotFound, "Organization not found")
+		return
+	}
+	response.Error(w, http.StatusInternalServerError, "Failed to update organization")
+}
+
+// record writes an entry about org, acted by the platform admin adminID.
+func (h *OrgHandler) record(r *http.Request, adminID string, org *repository.Organization, action, description string, changes map[string]interface{}) error {
+	entityType := "organization"
+	client := sessionClient(r)
+	entry := &repository.ActivityEntry{
+		OrganizationID: &org.ID,
+		UserID:         &adminID,
+		Action:         action,
+		EntityType:     &entityType,
+		EntityID:       &org.ID,
+		Description:    description,
+		Changes:        changes,
+	}
+	if client.UserAgent != "" {
+		entry.UserAgent = &client.UserAgent
+	}
+	if client.IPAddress != "" {
+		entry.IPAddress = &client.IPAddress
+	}
+	return h.Activity.Record(context.WithoutCancel(r.Context()), entry)
+}
+
+// orgID returns the {id} URL parameter if it is the caller's organization, and answers 404 otherwise.
+func (h *OrgHandler) orgID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	claims := auth.MustClaims(r.Context())
+
+	id := chi.URLParam(r, "id")
+	if id != claims.OrgID {
+		response.Error(w, http.StatusNotFound, "Organization not found")
+		return "", false
+	}
+	return id, true
+}
+
+// quotaExceeded answers a creation refused with a *repository.QuotaError.
+func quotaExceeded(w http.ResponseWriter, err error) {
+	var quota *repository.QuotaError
+	if !errors.As(err, &quota) {
+		response.ErrorCode(w, http.StatusConflict, codeQuotaExceeded, "Your organization has reached a limit of its plan")
+		return
+	}
+	response.ErrorCode(w, http.StatusConflict, codeQuotaExceeded,
+		fmt.Sprintf("Your organization has reached its limit of %d %s", quota.Limit, quotaNames[quota.Quota]))
+}
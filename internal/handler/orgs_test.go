@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/repository"
+)
+
+// serveOrg sends body (nil for none) to fn as a request on organization id by the caller described by claims.
+func serveOrg(t *testing.T, fn http.HandlerFunc, method, id string, claims *auth.Claims, body interface{}) (int, APIResponse) {
+	t.Helper()
+
+	var raw []byte
+	if body != nil {
+		raw, _ = json.Marshal(body)
+	}
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	req := httptest.NewRequest(method, "/orgs/"+id, bytes.NewBuffer(raw))
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = auth.WithClaims(ctx, claims)
+	rr := httptest.NewRecorder()
+	fn(rr, req.WithContext(ctx))
+
+	var resp APIResponse
+	_ = json.NewDecoder(rr.Body).Decode(&resp)
+	return rr.Code, resp
+}
+
+func TestUpdateOrg_Validation(t *testing.T) {
+	h := NewOrgHandler(nil, nil, nil) // Every case is refused before the database
+	caller := &auth.Claims{UserID: "admin", OrgID: "org-1", Role: "admin"}
+
+	tests := []struct {
+		name  string
+		body  map[string]interface{}
+		field string
+	}{
+		{"Short Name", map[string]interface{}{"name": "A"}, "Name"},
+		{"Unknown Timezone", map[string]interface{}{"settings": map[string]interface{}{"timezone": "Mars/Olympus"}}, "Timezone"},
+		{"Empty Timezone", map[string]interface{}{"settings": map[string]interface{}{"timezone": ""}}, "Timezone"},
+		{"Date Format", map[string]interface{}{"settings": map[string]interface{}{"date_format": "DD.MM.YY"}}, "DateFormat"},
+		{"Negative Retention", map[string]interface{}{"settings": map[string]interface{}{"audio_retention_days": -1}}, "AudioRetentionDays"},
+		{"Unknown Feature", map[string]interface{}{"settings": map[string]interface{}{"features": map[string]bool{"teleport": true}}}, "Features[teleport]"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			code, resp := serveOrg(t, h.Update, "PATCH", "org-1", caller, tc.body)
+			if code != http.StatusUnprocessableEntity || resp.Data[tc.field] == nil {
+				t.Errorf("Expected 422 on %s, got %d: %v", tc.field, code, resp.Data)
+			}
+		})
+	}
+
+	// Other organizations are not found, whatever the caller's role there
+	if code, _ := serveOrg(t, h.Update, "PATCH", "org-2", caller, map[string]string{"name": "Other"}); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another organization, got %d", code)
+	}
+}
+
+func TestUpdateSettingsInput_Apply(t *testing.T) {
+	settings := repository.DefaultOrgSettings()
+	settings.RequireTwoFactor = true
+
+	var input UpdateSettingsInput
+	if err := json.Unmarshal([]byte(`{"timezone": "Europe/Paris", "features": {"advanced_analytics": true}}`), &input); err != nil {
+		t.Fatal(err)
+	}
+	input.apply(&settings)
+
+	if settings.Timezone != "Europe/Paris" || settings.DateFormat != "MM/DD/YYYY" || !settings.RequireTwoFactor {
+		t.Errorf("Expected only the timezone to change, got %+v", settings)
+	}
+	if !settings.Features["advanced_analytics"] || !settings.Features["offline_sync"] {
+		t.Errorf("Expected features to be merged, got %v", settings.Features)
+	}
+}
+
+func TestQuotaExceeded(t *testing.T) {
+	rr := httptest.NewRecorder()
+	quotaExceeded(rr, fmt.Errorf("failed to accept: %w", &repository.QuotaError{Quota: repository.QuotaStaff, Limit: 50}))
+
+	body := rr.Body.String()
+	if rr.Code != http.StatusConflict || !strings.Contains(body, codeQuotaExceeded) || !strings.Contains(body, "limit of 50 staff members") {
+		t.Errorf("Expected 409 %s naming the limit, got %d: %s", codeQuotaExceeded, rr.Code, body)
+	}
+}
+
+func TestOrgIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	authHandler := newTestAuthHandler(db)
+	h := NewOrgHandler(authHandler.OrgRepo, authHandler.UserRepo, repository.NewActivityLogRepository(db))
+
+	admin, err := authHandler.UserRepo.GetUserByEmail(ctx, registerTestUser(t, authHandler, "org-admin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orgID string
+	if err := db.Pool.QueryRow(ctx, `SELECT organization_id FROM staff WHERE user_id = $1`, admin.ID).Scan(&orgID); err != nil {
+		t.Fatal(err)
+	}
+
+	caller := &auth.Claims{UserID: admin.ID, OrgID: orgID, Role: "admin"}
+
+	code, resp := serveOrg(t, h.Get, "GET", orgID, caller, nil)
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp.Data)
+	}
+	before := resp.Data["organization"].(map[string]interface{})
+
+	// Rename and change one setting
+	code, resp = serveOrg(t, h.Update, "PATCH", orgID, caller, map[string]interface{}{
+		"name":     "Renamed Clinic Élan",
+		"settings": map[string]interface{}{"date_format": "YYYY-MM-DD"},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp.Data)
+	}
+	after := resp.Data["organization"].(map[string]interface{})
+	if after["slug"] == before["slug"] || after["name"] != "Renamed Clinic Élan" {
+		t.Errorf("Expected the slug to follow the new name, got %v", after)
+	}
+	settings := after["settings"].(map[string]interface{})
+	if settings["date_format"] != "YYYY-MM-DD" || settings["timezone"] != before["settings"].(map[string]interface{})["timezone"] {
+		t.Errorf("Expected a partial settings update, got %v", settings)
+	}
+
+	// The registering admin holds one staff seat
+	code, resp = serveOrg(t, h.Usage, "GET", orgID, caller, nil)
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp.Data)
+	}
+	staff := resp.Data["usage"].(map[string]interface{})["staff"].(map[string]interface{})
+	if staff["used"] != float64(1) || staff["limit"] != float64(50) {
+		t.Errorf("Expected 1 of 50 staff, got %v", staff)
+	}
+}
+
+func TestOrgSuspensionIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	authHandler := newTestAuthHandler(db)
+	h := NewOrgHandler(authHandler.OrgRepo, authHandler.UserRepo, repository.NewActivityLogRepository(db))
+
+	// 1. A tenant admin, and a platform admin
+	email := registerTestUser(t, authHandler, "suspended")
+	member, err := authHandler.UserRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orgID string
+	if err := db.Pool.QueryRow(ctx, `SELECT organization_id FROM staff WHERE user_id = $1`, member.ID).Scan(&orgID); err != nil {
+		t.Fatal(err)
+	}
+	token := loginTestUser(t, authHandler, email).Data["access_token"].(string)
+
+	support, err := authHandler.UserRepo.GetUserByEmail(ctx, registerTestUser(t, authHandler, "suspender"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Pool.Exec(ctx, `UPDATE users SET is_platform_admin = true WHERE id = $1`, support.ID); err != nil {
+		t.Fatal(err)
+	}
+	platform := &auth.Claims{UserID: support.ID}
+
+	// 2. Only platform admins suspend
+	tenant := &auth.Claims{UserID: member.ID, OrgID: orgID, Role: "admin"}
+	if code, _ := serveOrg(t, h.Suspend, "POST", orgID, tenant, map[string]string{"reason": "Unpaid"}); code != http.StatusForbidden {
+		t.Fatalf("Expected 403 for a tenant admin, got %d", code)
+	}
+
+	// 3. Fully suspended: tokens and logins are refused
+	if code, resp := serveOrg(t, h.Suspend, "POST", orgID, platform, map[string]string{"reason": "Unpaid"}); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp.Data)
+	}
+	if _, err := authHandler.Verifier.Verify(ctx, token); !errors.Is(err, auth.ErrOrgSuspended) {
+		t.Errorf("Expected ErrOrgSuspended, got %v", err)
+	}
+	if rr := attemptLogin(authHandler, email, "TestPass123!"); rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), auth.CodeOrgSuspended) {
+		t.Errorf("Expected login to be refused with %s, got %d: %s", auth.CodeOrgSuspended, rr.Code, rr.Body.String())
+	}
+
+	// 4. Read-only: tokens are accepted, flagged read-only
+	if code, _ := serveOrg(t, h.Suspend, "POST", orgID, platform, map[string]interface{}{"reason": "Unpaid", "read_only": true}); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	claims, err := authHandler.Verifier.Verify(ctx, token)
+	if err != nil || !claims.ReadOnly {
+		t.Errorf("Expected read-only access, got %+v, %v", claims, err)
+	}
+	loginTestUser(t, authHandler, email)
+
+	// 5. Reinstated
+	if code, _ := serveOrg(t, h.Reinstate, "POST", orgID, platform, nil); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if claims, err := authHandler.Verifier.Verify(ctx, token); err != nil || claims.ReadOnly {
+		t.Errorf("Expected full access, got %+v, %v", claims, err)
+	}
+}
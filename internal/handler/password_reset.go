@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/mail"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+const (
+	// passwordResetLimit is how many reset emails an address may receive per window.
+	passwordResetLimit = 3
+	// passwordResetWindow is the window of passwordResetLimit.
+	passwordResetWindow = time.Hour
+	// passwordResetTimeout bounds the background work of a forgot-password request.
+	passwordResetTimeout = 30 * time.Second
+)
+
+// ForgotPasswordInput defines the payload for requesting a password reset.
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordInput defines the payload for setting a new password with a reset token.
+type ResetPasswordInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+// ChangePasswordInput defines the payload for changing the password of the current user.
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,password"`
+}
+
+// ForgotPassword emails a password reset link if an account exists for the address.
+// @Summary Forgot password
+// @Description Emails a single-use password reset link (valid for 1 hour). The response is the same whether
+// @Description or not the email is registered, and returns before any lookup is done.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body ForgotPasswordInput true "Account email"
+// @Success 202 {object} response.Response{data=map[string]string} "Request accepted"
+// @Failure 422 {object} response.Response "Validation Error"
+// @Router /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var input ForgotPasswordInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	// The lookup and email happen after responding, so the response time does not reveal
+	// whether the account exists.
+	ctx := context.WithoutCancel(r.Context())
+	h.tasks.Add(1)
+	go func() {
+		defer h.tasks.Done()
+
+		ctx, cancel := context.WithTimeout(ctx, passwordResetTimeout)
+		defer cancel()
+
+		if err := h.sendPasswordReset(ctx, input.Email); err != nil {
+			log.Printf("Failed to send password reset: %v", err)
+		}
+	}()
+
+	response.JSON(w, http.StatusAccepted, map[string]string{
+		"message": "If an account exists for this email, a reset link has been sent.",
+	})
+}
+
+// ResetPassword sets a new password using an emailed reset token and signs the user out everywhere.
+// @Summary Reset password
+// @Description Consumes a reset token, sets the new password and revokes every existing session of the user.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body ResetPasswordInput true "Reset token and new password"
+// @Success 200 {object} response.Response{data=map[string]string} "Password updated"
+// @Failure 400 {object} response.Response "Invalid or expired token"
+// @Failure 422 {object} response.Response "Validation Error"
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var input ResetPasswordInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	// 1. Check the token and the password against the account
+	user, err := h.UserRepo.GetUserByPasswordReset(r.Context(), auth.HashRefreshToken(input.Token))
+	if err != nil {
+		if errors.Is(err, repository.ErrResetTokenInvalid) {
+			response.Error(w, http.StatusBadRequest, "Invalid or expired token")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+	if ok := h.checkPersonalPassword(w, r, user, input.Password, "Password"); !ok {
+		return
+	}
+
+	// 2. Hash Password
+	hashedPW, err := h.Hasher.Hash(input.Password)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to process password")
+		return
+	}
+
+	// 3. Consume Token
+	userID, err := h.UserRepo.ResetPassword(r.Context(), auth.HashRefreshToken(input.Token), hashedPW)
+	if err != nil {
+		if errors.Is(err, repository.ErrResetTokenInvalid) {
+			response.Error(w, http.StatusBadRequest, "Invalid or expired token")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	// 4. Sign out everywhere: whoever knew the old password must not keep a session
+	if _, err := h.Sessions.RevokeUserSessions(r.Context(), userID, "password_reset"); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	h.clearRefreshCookie(w)
+	response.JSON(w, http.StatusOK, map[string]string{"message": "Password updated"})
+}
+
+// ChangePassword sets a new password for the current user and signs out their other sessions.
+// @Summary Change password
+// @Description Verifies the current password, sets the new one and revokes every other session of the user.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body ChangePasswordInput true "Current and new password"
+// @Success 200 {object} response.Response{data=map[string]interface{}} "Password updated"
+// @Failure 401 {object} response.Response "Wrong current password"
+// @Failure 422 {object} response.Response "Validation Error"
+// @Failure 429 {object} response.Response "Account temporarily locked"
+// @Router /auth/password/change [post]
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	var input ChangePasswordInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(r.Context(), claims.UserID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to load user")
+		return
+	}
+
+	// 1. Check the current password (failures count towards the lockout like a login)
+	if user.IsLocked() {
+		lockedError(w, *user.LockedUntil)
+		return
+	}
+	if err := auth.CheckPasswordHash(input.CurrentPassword, user.PasswordHash); err != nil {
+		lockedUntil, err := h.UserRepo.RecordFailedLogin(r.Context(), user.ID, h.Lockout.LockDuration)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to record login attempt")
+			return
+		}
+		if lockedUntil != nil {
+			lockedError(w, *lockedUntil)
+			return
+		}
+		response.Error(w, http.StatusUnauthorized, "Current password is incorrect")
+		return
+	}
+
+	// 2. Check the new password against the account
+	if ok := h.checkPersonalPassword(w, r, user, input.NewPassword, "NewPassword"); !ok {
+		return
+	}
+
+	// 3. Update
+	hashedPW, err := h.Hasher.Hash(input.NewPassword)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to process password")
+		return
+	}
+	if err := h.UserRepo.UpdatePassword(r.Context(), user.ID, hashedPW); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to update password")
+		return
+	}
+
+	// 4. Sign out other devices, keep this one
+	revoked, err := h.Sessions.RevokeOtherUserSessions(r.Context(), user.ID, claims.SessionID, "password_change")
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"message":          "Password updated",
+		"revoked_sessions": revoked,
+	})
+}
+
+// checkPersonalPassword rejects a new password containing the user's email or one of their organization names.
+// It writes a validation error for field and returns false if the password is rejected.
+func (h *AuthHandler) checkPersonalPassword(w http.ResponseWriter, r *http.Request, user *repository.User, password, field string) bool {
+	if !h.Passwords.RejectPersonal {
+		return true
+	}
+
+	memberships, err := h.StaffRepo.ListMembershipsByUser(r.Context(), user.ID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to load memberships")
+		return false
+	}
+	orgNames := make([]string, len(memberships))
+	for i, m := range memberships {
+		orgNames[i] = m.OrganizationName
+	}
+
+	if h.Passwords.ContainsPersonal(password, user.Email, orgNames...) {
+		response.FieldError(w, field, auth.TagPersonalPassword)
+		return false
+	}
+	return true
+}
+
+// sendPasswordReset creates a reset token for the account of email and emails the link.
+// Unknown, inactive and rate-limited addresses are silently ignored.
+func (h *AuthHandler) sendPasswordReset(ctx context.Context, email string) error {
+	if ok, _ := h.resetLimiter.Allow(normalizeEmail(email)); !ok {
+		return nil
+	}
+
+	user, err := h.UserRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	token, err := auth.NewPasswordResetToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(auth.PasswordResetTokenDuration)
+	if err := h.UserRepo.CreatePasswordReset(ctx, user.ID, auth.HashRefreshToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	link := h.AppURL + "/reset-password?token=" + url.QueryEscape(token)
+	return h.Mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nSomeone asked to reset the password of your account. To choose a new password, open this link:\n\n%s\n\n"+
+				"The link expires in %d minutes and can be used once. If you did not ask for this, you can ignore this email.\n",
+			user.FirstName, link, int(auth.PasswordResetTokenDuration.Minutes()),
+		),
+	})
+}
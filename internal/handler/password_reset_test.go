@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/off-by-2/sal/internal/auth"
+)
+
+// resetToken extracts the token from the last reset email sent to email.
+func resetToken(t *testing.T, handler *AuthHandler, email string) string {
+	t.Helper()
+
+	msg, ok := handler.Mailer.(*recordingMailer).last(email)
+	if !ok || msg.Subject != "Reset your password" {
+		t.Fatalf("No reset email sent to %s", email)
+	}
+	i := strings.Index(msg.Body, "http://app.test/reset-password?")
+	if i < 0 {
+		t.Fatalf("No reset link in %q", msg.Body)
+	}
+	link, err := url.Parse(strings.Fields(msg.Body[i:])[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link.Query().Get("token")
+}
+
+// TestPasswordResetIntegration covers the full forgot/reset flow.
+func TestPasswordResetIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	handler := newTestAuthHandler(db)
+	email := registerTestUser(t, handler, "reset")
+	oldSession := loginTestUser(t, handler, email)
+
+	// 1. Forgot: same answer for known and unknown emails
+	code, known := postWithBearer(t, handler.ForgotPassword, "", map[string]string{"email": email})
+	unknownEmail := fmt.Sprintf("nobody-%d@example.com", time.Now().UnixNano())
+	_, unknown := postWithBearer(t, handler.ForgotPassword, "", map[string]string{"email": unknownEmail})
+	if err := handler.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", code)
+	}
+	if known.Data["message"] != unknown.Data["message"] {
+		t.Errorf("Responses differ: %v vs %v", known.Data, unknown.Data)
+	}
+	if _, ok := handler.Mailer.(*recordingMailer).last(unknownEmail); ok {
+		t.Error("Expected no email for unknown address")
+	}
+
+	// 2. Reset
+	token := resetToken(t, handler, email)
+	body := map[string]string{"token": token, "password": "NewPass456!"}
+	if code, resp := postWithBearer(t, handler.ResetPassword, "", body); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp.Data)
+	}
+
+	// 3. Single use
+	if code, _ := postWithBearer(t, handler.ResetPassword, "", body); code != http.StatusBadRequest {
+		t.Errorf("Expected reused token to be rejected, got %d", code)
+	}
+
+	// 4. Old sessions are gone, the new password works
+	if code, _ := postWithBearer(t, handler.Refresh, "", map[string]string{
+		"refresh_token": oldSession.Data["refresh_token"].(string),
+	}); code != http.StatusUnauthorized {
+		t.Errorf("Expected old session to be revoked, got %d", code)
+	}
+	if rr := attemptLogin(handler, email, "NewPass456!"); rr.Code != http.StatusOK {
+		t.Errorf("Expected login with new password, got %d", rr.Code)
+	}
+}
+
+// TestChangePasswordIntegration verifies the policy and that other sessions are signed out.
+func TestChangePasswordIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	handler := newTestAuthHandler(db)
+	email := registerTestUser(t, handler, "change")
+
+	other := loginTestUser(t, handler, email)
+	current := loginTestUser(t, handler, email)
+	change := auth.Authenticate(handler.Verifier)(http.HandlerFunc(handler.ChangePassword))
+	access := current.Data["access_token"].(string)
+
+	// 1. Wrong current password
+	code, _ := postWithBearer(t, change.ServeHTTP, access, map[string]string{
+		"current_password": "WrongPass123!",
+		"new_password":     "NewPass456!",
+	})
+	if code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for wrong current password, got %d", code)
+	}
+
+	// 2. New password containing the org name ("Test Org")
+	code, _ = postWithBearer(t, change.ServeHTTP, access, map[string]string{
+		"current_password": "TestPass123!",
+		"new_password":     "MyTestOrg#99",
+	})
+	if code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for personal password, got %d", code)
+	}
+
+	// 3. Success signs out the other session only
+	code, resp := postWithBearer(t, change.ServeHTTP, access, map[string]string{
+		"current_password": "TestPass123!",
+		"new_password":     "NewPass456!",
+	})
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp.Data)
+	}
+
+	if code, _ := postWithBearer(t, handler.Refresh, "", map[string]string{
+		"refresh_token": other.Data["refresh_token"].(string),
+	}); code != http.StatusUnauthorized {
+		t.Errorf("Expected other session to be revoked, got %d", code)
+	}
+	if code, _ := postWithBearer(t, handler.Refresh, "", map[string]string{
+		"refresh_token": current.Data["refresh_token"].(string),
+	}); code != http.StatusOK {
+		t.Errorf("Expected current session to survive, got %d", code)
+	}
+}
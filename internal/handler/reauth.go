@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// ReauthenticateInput defines the payload for confirming the user's identity.
+// Exactly one of Password or Code (TOTP) is required.
+type ReauthenticateInput struct {
+	Password string `json:"password" validate:"required_without=Code,excluded_with=Code"`
+	Code     string `json:"code" validate:"omitempty,len=6,numeric"`
+}
+
+// Reauthenticate confirms the identity of a signed-in user before a sensitive action.
+// @Summary Reauthenticate
+// @Description Checks the user's password or a TOTP code and returns an access token with a fresh auth_time.
+// @Description Sensitive routes (deleting or overriding data) answer 401 reauthentication_required when the last
+// @Description authentication is older than REAUTH_MAX_AGE; call this endpoint and retry with the new token.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body ReauthenticateInput true "Password or TOTP code"
+// @Success 200 {object} response.Response{data=map[string]interface{}} "Access token"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "No password or two-factor authentication to confirm with"
+// @Failure 429 {object} response.Response "Account temporarily locked"
+// @Router /auth/reauthenticate [post]
+func (h *AuthHandler) Reauthenticate(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	var input ReauthenticateInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	// 1. Get User
+	user, err := h.UserRepo.GetUserByID(r.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			response.Error(w, http.StatusUnauthorized, "Account is inactive")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to load user")
+		return
+	}
+	if !user.IsActive {
+		response.Error(w, http.StatusUnauthorized, "Account is inactive")
+		return
+	}
+
+	// 2. Check Lockout (failures count like failed logins)
+	if user.IsLocked() {
+		lockedError(w, *user.LockedUntil)
+		return
+	}
+
+	// 3. Check Credentials
+	var method string
+	var ok bool
+	switch {
+	case input.Password != "":
+		if user.PasswordHash == "" {
+			response.Error(w, http.StatusForbidden, "This account has no password. Sign in again to continue.")
+			return
+		}
+		method, ok = auth.AMRPassword, auth.CheckPasswordHash(input.Password, user.PasswordHash) == nil
+	default:
+		if !user.TwoFactorEnabled || user.TwoFactorSecret == nil {
+			response.Error(w, http.StatusForbidden, "Two-factor authentication is not enabled")
+			return
+		}
+		method, ok = auth.AMROTP, h.checkSecondFactor(r, user, input.Code, "")
+	}
+	if !ok {
+		lockedUntil, err := h.UserRepo.RecordFailedLogin(r.Context(), user.ID, h.Lockout.LockDuration)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to record login attempt")
+			return
+		}
+		if lockedUntil != nil {
+			lockedError(w, *lockedUntil)
+			return
+		}
+		response.Error(w, http.StatusUnauthorized, "Invalid credentials")
+		return
+	}
+
+	// 4. Record it on the session, so refreshed tokens keep the new auth_time and the factors already proven
+	amr := auth.WithAMR(claims.AMR, method)
+	authTime, err := h.Sessions.SetSessionAuthentication(r.Context(), claims.SessionID, amr)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionRevoked) {
+			response.Error(w, http.StatusUnauthorized, "Session revoked")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to update session")
+		return
+	}
+	if err := h.UserRepo.UnlockUser(r.Context(), user.ID); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+
+	// 5. Issue Access Token
+	fresh := auth.Claims{
+		UserID:    claims.UserID,
+		OrgID:     claims.OrgID,
+		Role:      claims.Role,
+		SessionID: claims.SessionID,
+	}
+	fresh.SetAuthentication(authTime, amr)
+	accessToken, err := auth.SignAccessTokenFor(fresh, h.AccessTokenTTL, h.Keys)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Token generation failed")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"auth_time":    authTime,
+	})
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/off-by-2/sal/internal/auth"
+)
+
+func TestReauthenticateIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	handler := newTestAuthHandler(db)
+	reauth := authMiddleware(handler, handler.Reauthenticate)
+
+	email := registerTestUser(t, handler, "reauth")
+	token := loginTestUser(t, handler, email).Data["access_token"].(string)
+
+	// 1. Login records the method and time
+	claims, err := handler.Verifier.Verify(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claims.AMR) != 1 || claims.AMR[0] != auth.AMRPassword || !claims.AuthenticatedWithin(time.Minute, time.Now()) {
+		t.Fatalf("Unexpected authentication claims: %v %v", claims.AuthTime, claims.AMR)
+	}
+
+	// Pretend the login was long ago
+	if _, err := db.Pool.Exec(ctx, `UPDATE user_sessions SET auth_time = now() - interval '1 hour' WHERE id = $1`, claims.SessionID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2. Wrong password
+	if code, _ := postWithBearer(t, reauth, token, map[string]string{"password": "WrongPass123!"}); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong password, got %d", code)
+	}
+
+	// 3. TOTP without two-factor authentication
+	if code, _ := postWithBearer(t, reauth, token, map[string]string{"code": "123456"}); code != http.StatusForbidden {
+		t.Errorf("Expected 403 without two-factor authentication, got %d", code)
+	}
+
+	// 4. Password
+	code, resp := postWithBearer(t, reauth, token, map[string]string{"password": "TestPass123!"})
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp.Data)
+	}
+	fresh, err := handler.Verifier.Verify(ctx, resp.Data["access_token"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh.SessionID != claims.SessionID || !fresh.AuthenticatedWithin(time.Minute, time.Now()) {
+		t.Errorf("Expected a fresh token for the same session, got %+v", fresh)
+	}
+
+	// 5. The session keeps the new time for refreshed tokens
+	session, err := handler.Sessions.ListUserSessions(ctx, claims.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(session) != 1 || time.Since(session[0].AuthTime) > time.Minute {
+		t.Errorf("Expected the session authentication time to be updated, got %+v", session)
+	}
+
+	// 6. Reauthenticating with one factor keeps the others of the session
+	claims.SetAuthentication(time.Now().Add(-time.Hour), []string{auth.AMRPassword, auth.AMROTP, auth.AMRMultiFactor})
+	mfaToken, err := auth.SignAccessTokenFor(*claims, handler.AccessTokenTTL, handler.Keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, resp = postWithBearer(t, reauth, mfaToken, map[string]string{"password": "TestPass123!"})
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp.Data)
+	}
+	fresh, err = handler.Verifier.Verify(ctx, resp.Data["access_token"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(fresh.AMR, []string{auth.AMRPassword, auth.AMROTP, auth.AMRMultiFactor}) {
+		t.Errorf("Expected the session to stay multi-factor, got %v", fresh.AMR)
+	}
+}
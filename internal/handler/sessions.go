@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// maxUserAgentLength matches user_sessions.user_agent.
+const maxUserAgentLength = 512
+
+// sessionClient describes the client of a request for its session.
+// The IP is the one resolved by middleware.RealIP; it is left empty if it does not parse.
+func sessionClient(r *http.Request) repository.SessionClient {
+	ua := r.UserAgent()
+	if len(ua) > maxUserAgentLength {
+		ua = strings.ToValidUTF8(ua[:maxUserAgentLength], "")
+	}
+	return repository.SessionClient{UserAgent: ua, IPAddress: clientIP(r)}
+}
+
+// clientIP returns the IP address of r.RemoteAddr, with or without port, or "" if there is none.
+func clientIP(r *http.Request) string {
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// ListSessions lists the active sessions of the current user.
+// @Summary List sessions
+// @Description Returns the devices the user is signed in on, most recently used first. The session of the access token is marked "current".
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=map[string][]repository.Session} "Sessions"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Router /auth/sessions [get]
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	sessions, err := h.Sessions.ListUserSessions(r.Context(), claims.UserID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to list sessions")
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == claims.SessionID
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{"sessions": sessions})
+}
+
+// RevokeSession signs the current user out of one of their sessions.
+// @Summary Revoke session
+// @Description Revokes one of the user's sessions, e.g. a lost device. Revoking the current session logs out.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} response.Response{data=map[string]string} "Session revoked"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 404 {object} response.Response "Not Found"
+// @Router /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	id := chi.URLParam(r, "id")
+	if h.Validator.Var(id, "uuid") != nil {
+		response.Error(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	if err := h.Sessions.RevokeUserSession(r.Context(), claims.UserID, id, "user_revoked"); err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			response.Error(w, http.StatusNotFound, "Session not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	if id == claims.SessionID {
+		h.clearRefreshCookie(w)
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"message": "Session revoked"})
+}
+
+// ListSessions lists the active sessions a staff member holds in the caller's organization.
+// Requires the "staff.manage" permission.
+// @Summary List staff sessions
+// @Description Returns the devices a staff member is signed in on in the caller's organization, most recently used first.
+// @Tags staff
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Staff ID"
+// @Success 200 {object} response.Response{data=map[string][]repository.Session} "Sessions"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 404 {object} response.Response "Not Found"
+// @Router /staff/{id}/sessions [get]
+func (h *StaffHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	// 1. The staff member must belong to the caller's organization
+	target, ok := h.orgStaff(w, r, claims.OrgID)
+	if !ok {
+		return
+	}
+
+	// 2. Only sessions in this organization are the admin's business
+	sessions, err := h.Sessions.ListUserOrgSessions(r.Context(), target.UserID, target.OrganizationID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to list sessions")
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == claims.SessionID
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{"sessions": sessions})
+}
+
+// RevokeSession revokes a session a staff member holds in the caller's organization.
+// Requires the "staff.manage" permission.
+// @Summary Revoke staff session
+// @Description Signs a staff member out of one device in the caller's organization.
+// @Tags staff
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Staff ID"
+// @Param sessionID path string true "Session ID"
+// @Success 200 {object} response.Response{data=map[string]string} "Session revoked"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 404 {object} response.Response "Not Found"
+// @Router /staff/{id}/sessions/{sessionID} [delete]
+func (h *StaffHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	// 1. The staff member must belong to the caller's organization
+	target, ok := h.orgStaff(w, r, claims.OrgID)
+	if !ok {
+		return
+	}
+
+	// 2. Revoke, if the session is theirs and in this organization
+	id := chi.URLParam(r, "sessionID")
+	if h.Validator.Var(id, "uuid") != nil {
+		response.Error(w, http.StatusNotFound, "Session not found")
+		return
+	}
+	err := h.Sessions.RevokeUserOrgSession(r.Context(), target.UserID, target.OrganizationID, id, "admin_revoked")
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			response.Error(w, http.StatusNotFound, "Session not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{"message": "Session revoked"})
+}
+
+// orgStaff loads the staff member of the "id" URL parameter in orgID.
+// It writes an error response and returns false if there is none.
+func (h *StaffHandler) orgStaff(w http.ResponseWriter, r *http.Request, orgID string) (*repository.Staff, bool) {
+	staff, err := h.StaffRepo.GetStaffByID(r.Context(), orgID, chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			response.Error(w, http.StatusNotFound, "Staff not found")
+			return nil, false
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to load staff")
+		return nil, false
+	}
+	return staff, true
+}
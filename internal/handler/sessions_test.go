@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/off-by-2/sal/internal/auth"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		remoteAddr string
+		want       string
+	}{
+		{"203.0.113.7:52100", "203.0.113.7"},
+		{"203.0.113.7", "203.0.113.7"}, // As set by middleware.RealIP
+		{"[2001:db8::1]:443", "2001:db8::1"},
+		{"2001:db8::1", "2001:db8::1"},
+		{"", ""},
+		{"not-an-ip", ""},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tc.remoteAddr
+		if got := clientIP(req); got != tc.want {
+			t.Errorf("clientIP(%q) = %q, want %q", tc.remoteAddr, got, tc.want)
+		}
+	}
+}
+
+// sessionsRequest calls fn as the holder of accessToken, with chi URL parameters.
+func sessionsRequest(t *testing.T, handler *AuthHandler, fn http.HandlerFunc, method, accessToken string, params map[string]string) (int, APIResponse) {
+	t.Helper()
+
+	claims, err := handler.Verifier.Verify(context.Background(), accessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rctx := chi.NewRouteContext()
+	for k, v := range params {
+		rctx.URLParams.Add(k, v)
+	}
+	req := httptest.NewRequest(method, "/sessions", nil)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	rr := httptest.NewRecorder()
+	fn(rr, req.WithContext(auth.WithClaims(ctx, claims)))
+
+	var resp APIResponse
+	_ = json.NewDecoder(rr.Body).Decode(&resp)
+	return rr.Code, resp
+}
+
+func TestSessionsIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	handler := newTestAuthHandler(db)
+
+	// 1. Two devices
+	email := registerTestUser(t, handler, "sessions")
+	laptop := loginTestUser(t, handler, email).Data["access_token"].(string)
+	phone := loginTestUser(t, handler, email).Data["access_token"].(string)
+
+	code, resp := sessionsRequest(t, handler, handler.ListSessions, "GET", laptop, nil)
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	sessions := resp.Data["sessions"].([]interface{})
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+
+	var phoneID string
+	for _, s := range sessions {
+		s := s.(map[string]interface{})
+		if !s["current"].(bool) {
+			phoneID = s["id"].(string)
+		}
+	}
+	if phoneID == "" {
+		t.Fatal("Expected exactly one current session")
+	}
+
+	// 2. Sign the phone out from the laptop
+	code, _ = sessionsRequest(t, handler, handler.RevokeSession, "DELETE", laptop, map[string]string{"id": phoneID})
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if _, err := handler.Verifier.Verify(context.Background(), phone); err == nil {
+		t.Error("Expected the phone's access token to be revoked")
+	}
+
+	// 3. Other users' sessions are not found
+	other := loginTestUser(t, handler, registerTestUser(t, handler, "sessions-other")).Data["access_token"].(string)
+	code, _ = sessionsRequest(t, handler, handler.RevokeSession, "DELETE", other, map[string]string{"id": phoneID})
+	if code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another user's session, got %d", code)
+	}
+}
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/config"
+	"github.com/off-by-2/sal/internal/oidc"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+const (
+	// oidcFlowCookieName is the name of the HTTP-only cookie carrying the sealed sign-in flow.
+	oidcFlowCookieName = "oidc_flow"
+	// oidcFlowCookiePath limits the flow cookie to the social sign-in routes.
+	oidcFlowCookiePath = "/api/v1/auth/oidc"
+)
+
+// errEmailNotVerified is returned when the provider does not vouch for the email of a new identity.
+var errEmailNotVerified = errors.New("email not verified by provider")
+
+// errAccountNotVerified is returned when an identity matches an account whose owner never verified its email.
+var errAccountNotVerified = errors.New("account email not verified")
+
+// OIDCStartOutput is the response of starting a social sign-in.
+type OIDCStartOutput struct {
+	AuthorizationURL string `json:"authorization_url"`
+	Flow             string `json:"flow"` // Also set as cookie; non-browser clients send it back in the callback
+}
+
+// OIDCCallbackInput defines the payload for completing a social sign-in.
+type OIDCCallbackInput struct {
+	Code     string `json:"code" validate:"required"`
+	State    string `json:"state" validate:"required"`
+	Flow     string `json:"flow"`                             // Optional when sent as cookie
+	DeviceID string `json:"device_id" validate:"max=255"`     // Optional, identifies the client device
+	OrgID    string `json:"org_id" validate:"omitempty,uuid"` // Optional, defaults to the oldest membership
+}
+
+// oidcProviders builds the social sign-in providers enabled in cfg.
+func oidcProviders(cfg *config.Config) map[string]*oidc.Provider {
+	redirect := strings.TrimSuffix(cfg.OIDCRedirectURL, "/") + "/"
+	providers := make(map[string]*oidc.Provider)
+
+	if cfg.GoogleClientID != "" {
+		providers["google"] = oidc.NewProvider(oidc.Config{
+			Name:         "google",
+			Issuer:       oidc.GoogleIssuer,
+			ClientID:     cfg.GoogleClientID,
+			ClientSecret: cfg.GoogleClientSecret,
+			RedirectURL:  redirect + "google",
+		})
+	}
+	if cfg.AppleClientID != "" {
+		// Apple only returns the email when asked for it, and then requires the code to be posted back
+		providers["apple"] = oidc.NewProvider(oidc.Config{
+			Name:         "apple",
+			Issuer:       oidc.AppleIssuer,
+			ClientID:     cfg.AppleClientID,
+			ClientSecret: cfg.AppleClientSecret,
+			RedirectURL:  redirect + "apple",
+			Scopes:       []string{"openid", "email", "name"},
+			AuthParams:   url.Values{"response_mode": {"form_post"}},
+		})
+	}
+	if cfg.MicrosoftClientID != "" {
+		providers["microsoft"] = oidc.NewProvider(oidc.Config{
+			Name:         "microsoft",
+			Issuer:       fmt.Sprintf(oidc.MicrosoftIssuer, cfg.MicrosoftTenant),
+			ClientID:     cfg.MicrosoftClientID,
+			ClientSecret: cfg.MicrosoftClientSecret,
+			RedirectURL:  redirect + "microsoft",
+		})
+	}
+	return providers
+}
+
+// StartOIDC starts a social sign-in.
+// @Summary Start social sign-in
+// @Description Returns the provider URL to send the user to (authorization code flow with PKCE).
+// @Description The state of the flow is kept in an HTTP-only cookie and returned as "flow" for non-browser clients.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider" Enums(google, apple, microsoft)
+// @Success 200 {object} response.Response{data=OIDCStartOutput} "Authorization URL"
+// @Failure 404 {object} response.Response "Unknown provider"
+// @Failure 502 {object} response.Response "Provider unavailable"
+// @Router /auth/oidc/{provider}/start [post]
+func (h *AuthHandler) StartOIDC(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.OIDC[chi.URLParam(r, "provider")]
+	if !ok {
+		response.Error(w, http.StatusNotFound, "Unknown sign-in provider")
+		return
+	}
+
+	// 1. Fresh state, nonce and PKCE verifier
+	req, err := oidc.NewAuthRequest(provider.Name())
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to start sign-in")
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(r.Context(), req)
+	if err != nil {
+		log.Printf("Failed to start %s sign-in: %v", provider.Name(), err)
+		response.Error(w, http.StatusBadGateway, "Sign-in provider unavailable")
+		return
+	}
+
+	// 2. The client keeps the sealed flow until the callback
+	flow, err := req.Seal(h.JWTSecret)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to start sign-in")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookieName,
+		Value:    flow,
+		Expires:  time.Now().Add(oidc.FlowDuration),
+		HttpOnly: true,
+		Secure:   h.SecureCookies,
+		Path:     oidcFlowCookiePath,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	response.JSON(w, http.StatusOK, OIDCStartOutput{AuthorizationURL: authURL, Flow: flow})
+}
+
+// OIDCCallback completes a social sign-in.
+// @Summary Complete social sign-in
+// @Description Exchanges the code the provider redirected back with, verifies the ID token and logs the user in.
+// @Description The identity is linked to the account with the same verified email, or a new account without password is created.
+// @Description The response is the same as POST /auth/login, including two-factor challenges.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider" Enums(google, apple, microsoft)
+// @Param input body OIDCCallbackInput true "Code and state from the provider redirect"
+// @Success 200 {object} response.Response{data=map[string]interface{}} "Tokens or two-factor challenge"
+// @Failure 401 {object} response.Response "Sign-in failed"
+// @Failure 403 {object} response.Response "Email not verified by provider"
+// @Failure 404 {object} response.Response "Unknown provider"
+// @Failure 409 {object} response.Response "Account linked to another provider, or its email is not verified"
+// @Router /auth/oidc/{provider}/callback [post]
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.OIDC[chi.URLParam(r, "provider")]
+	if !ok {
+		response.Error(w, http.StatusNotFound, "Unknown sign-in provider")
+		return
+	}
+
+	var input OIDCCallbackInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	// 1. Check the flow was started by this client, for this provider
+	flow := input.Flow
+	if flow == "" {
+		if c, err := r.Cookie(oidcFlowCookieName); err == nil {
+			flow = c.Value
+		}
+	}
+	req, err := oidc.OpenAuthRequest(flow, h.JWTSecret, provider.Name(), input.State)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "Invalid or expired sign-in")
+		return
+	}
+	h.clearOIDCFlowCookie(w)
+
+	// 2. Redeem the code and verify the ID token
+	identity, err := provider.SignIn(r.Context(), input.Code, req)
+	if err != nil {
+		log.Printf("Failed %s sign-in: %v", provider.Name(), err)
+		response.Error(w, http.StatusUnauthorized, "Sign-in with provider failed")
+		return
+	}
+
+	// 3. Link or create the account
+	user, err := h.providerUser(r.Context(), identity)
+	if err != nil {
+		switch {
+		case errors.Is(err, errEmailNotVerified):
+			response.Error(w, http.StatusForbidden, "Email address is not verified by the provider")
+		case errors.Is(err, repository.ErrProviderLinked), errors.Is(err, repository.ErrDuplicateEmail):
+			response.Error(w, http.StatusConflict, "This email is linked to another sign-in method")
+		case errors.Is(err, errAccountNotVerified):
+			response.Error(w, http.StatusConflict, "Verify the email of your existing account before signing in with this provider")
+		default:
+			response.Error(w, http.StatusInternalServerError, "Failed to load user")
+		}
+		return
+	}
+
+	// 4. Check Active
+	if !user.IsActive {
+		response.Error(w, http.StatusUnauthorized, "Account is inactive")
+		return
+	}
+
+	h.completeLogin(w, r, user, input.OrgID, input.DeviceID, []string{auth.AMRFederated})
+}
+
+// providerUser returns the account of a verified provider identity.
+// Identities are matched by provider subject first, then by verified email (linking the account);
+// unknown identities get a new account without password.
+// Accounts whose email was never verified are not linked: whoever registered them may not own the address,
+// and would keep signing in with their password.
+func (h *AuthHandler) providerUser(ctx context.Context, id *oidc.Identity) (*repository.User, error) {
+	// 1. Known identity
+	user, err := h.UserRepo.GetUserByProvider(ctx, id.Provider, id.Subject)
+	if err == nil || !errors.Is(err, repository.ErrUserNotFound) {
+		return user, err
+	}
+
+	// Matching by email is only safe if the provider has verified it
+	if id.Email == "" || !id.EmailVerified {
+		return nil, errEmailNotVerified
+	}
+
+	// 2. Existing account with the same email
+	user, err = h.UserRepo.GetUserByEmail(ctx, id.Email)
+	if err == nil {
+		if !user.EmailVerified {
+			return nil, errAccountNotVerified
+		}
+		if err := h.UserRepo.LinkProvider(ctx, user.ID, id.Provider, id.Subject); err != nil {
+			return nil, err
+		}
+		user.AuthProvider = id.Provider
+		user.EmailVerified = true
+		return user, nil
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, err
+	}
+
+	// 3. New account
+	user = &repository.User{
+		Email:        id.Email,
+		FirstName:    id.GivenName,
+		LastName:     id.FamilyName,
+		AuthProvider: id.Provider,
+	}
+	if err := h.UserRepo.CreateProviderUser(ctx, user, id.Subject); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// clearOIDCFlowCookie instructs the client to drop the sign-in flow cookie.
+func (h *AuthHandler) clearOIDCFlowCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookieName,
+		Value:    "",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.SecureCookies,
+		Path:     oidcFlowCookiePath,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
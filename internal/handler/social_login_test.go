@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/off-by-2/sal/internal/oidc"
+	"github.com/off-by-2/sal/internal/oidc/oidctest"
+)
+
+// withProvider sets the chi provider URL parameter on a request.
+func withProvider(req *http.Request, provider string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("provider", provider)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+// newTestIssuer starts a fake issuer and enables it on the handler as "google".
+func newTestIssuer(t *testing.T, handler *AuthHandler) *oidctest.Issuer {
+	t.Helper()
+	issuer := oidctest.New(t, "sal-test")
+	handler.OIDC = map[string]*oidc.Provider{
+		"google": oidc.NewProvider(issuer.Config("google", "http://app.test/auth/callback/google")),
+	}
+	return issuer
+}
+
+// oidcSignIn runs a complete social sign-in as u and returns the callback recorder.
+func oidcSignIn(t *testing.T, handler *AuthHandler, issuer *oidctest.Issuer, u oidctest.User) *httptest.ResponseRecorder {
+	t.Helper()
+
+	// 1. Start
+	req := withProvider(httptest.NewRequest("POST", "/auth/oidc/google/start", nil), "google")
+	rr := httptest.NewRecorder()
+	handler.StartOIDC(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Start returned %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	var started APIResponse
+	_ = json.NewDecoder(rr.Body).Decode(&started)
+	cookies := rr.Result().Cookies()
+
+	// 2. Sign in at the provider
+	code, state, err := issuer.Authorize(started.Data["authorization_url"].(string), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3. Callback, carrying the flow cookie like a browser would
+	body, _ := json.Marshal(map[string]string{"code": code, "state": state})
+	req = withProvider(httptest.NewRequest("POST", "/auth/oidc/google/callback", bytes.NewBuffer(body)), "google")
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rr = httptest.NewRecorder()
+	handler.OIDCCallback(rr, req)
+	return rr
+}
+
+func TestOIDCIntegration_NewUser(t *testing.T) {
+	db := setupTestDB(t)
+	handler := newTestAuthHandler(db)
+	issuer := newTestIssuer(t, handler)
+
+	u := oidctest.User{
+		Subject:       fmt.Sprintf("sub-%d", time.Now().UnixNano()),
+		Email:         fmt.Sprintf("oidc-new-%d@example.com", time.Now().UnixNano()),
+		EmailVerified: true,
+		GivenName:     "Grace",
+	}
+
+	// 1. First sign-in creates the account
+	rr := oidcSignIn(t, handler, issuer, u)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	var resp APIResponse
+	_ = json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.Data["access_token"] == nil {
+		t.Error("Expected an access token")
+	}
+
+	user, err := handler.UserRepo.GetUserByEmail(context.Background(), u.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.AuthProvider != "google" || !user.EmailVerified || user.PasswordHash != "" {
+		t.Errorf("Unexpected provider account: %+v", user)
+	}
+
+	// 2. Second sign-in finds it by subject
+	if rr := oidcSignIn(t, handler, issuer, u); rr.Code != http.StatusOK {
+		t.Errorf("Expected repeat sign-in to succeed, got %d", rr.Code)
+	}
+
+	// 3. No password login, and no lockout either
+	for i := 0; i < 4; i++ {
+		if rr := attemptLogin(handler, u.Email, "TestPass123!"); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected password login to be refused with 401, got %d", rr.Code)
+		}
+	}
+}
+
+func TestOIDCIntegration_LinksVerifiedEmail(t *testing.T) {
+	db := setupTestDB(t)
+	handler := newTestAuthHandler(db)
+	issuer := newTestIssuer(t, handler)
+
+	email := registerTestUser(t, handler, "oidc-link")
+	u := oidctest.User{Subject: fmt.Sprintf("sub-%d", time.Now().UnixNano()), Email: email}
+
+	// 1. An unverified email is not enough to take over the account
+	if rr := oidcSignIn(t, handler, issuer, u); rr.Code != http.StatusForbidden {
+		t.Fatalf("Expected 403 for unverified email, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+
+	// 2. Nor is a verified identity while the account's own email is unverified
+	u.EmailVerified = true
+	if rr := oidcSignIn(t, handler, issuer, u); rr.Code != http.StatusConflict {
+		t.Fatalf("Expected 409 for an unverified account, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	user, err := handler.UserRepo.GetUserByEmail(context.Background(), email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.AuthProvider != "email" {
+		t.Errorf("Expected the account to stay unlinked, got %q", user.AuthProvider)
+	}
+
+	// 3. Once the account is verified, the identity links it, and the user lands in their organization
+	if err := handler.UserRepo.MarkEmailVerified(context.Background(), user.ID, email); err != nil {
+		t.Fatal(err)
+	}
+	rr := oidcSignIn(t, handler, issuer, u)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	var resp APIResponse
+	_ = json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.Data["organization_id"] == "" {
+		t.Error("Expected the existing membership to be used")
+	}
+
+	// 4. The password keeps working
+	loginTestUser(t, handler, email)
+}
+
+func TestOIDCCallback_InvalidState(t *testing.T) {
+	handler := newTestAuthHandler(nil)
+	issuer := newTestIssuer(t, handler)
+
+	req := withProvider(httptest.NewRequest("POST", "/auth/oidc/google/start", nil), "google")
+	rr := httptest.NewRecorder()
+	handler.StartOIDC(rr, req)
+	var started APIResponse
+	_ = json.NewDecoder(rr.Body).Decode(&started)
+
+	code, _, err := issuer.Authorize(started.Data["authorization_url"].(string), oidctest.User{Subject: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Forged state, and a missing flow
+	for _, flow := range []interface{}{started.Data["flow"], ""} {
+		body, _ := json.Marshal(map[string]interface{}{"code": code, "state": "forged", "flow": flow})
+		req := withProvider(httptest.NewRequest("POST", "/auth/oidc/google/callback", bytes.NewBuffer(body)), "google")
+		rr := httptest.NewRecorder()
+		handler.OIDCCallback(rr, req)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d. Body: %s", rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestOIDCStart_UnknownProvider(t *testing.T) {
+	handler := newTestAuthHandler(nil)
+
+	req := withProvider(httptest.NewRequest("POST", "/auth/oidc/myspace/start", nil), "myspace")
+	rr := httptest.NewRecorder()
+	handler.StartOIDC(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rr.Code)
+	}
+}
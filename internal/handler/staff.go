@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/authz"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// StaffHandler handles staff administration requests.
+type StaffHandler struct {
+	StaffRepo *repository.StaffRepository
+	UserRepo  *repository.UserRepository
+	Sessions  *repository.SessionRepository
+	Validator *validator.Validate
+}
+
+// NewStaffHandler creates a new StaffHandler.
+func NewStaffHandler(
+	staffEq *repository.StaffRepository,
+	userEq *repository.UserRepository,
+	sessionEq *repository.SessionRepository,
+) *StaffHandler {
+	return &StaffHandler{
+		StaffRepo: staffEq,
+		UserRepo:  userEq,
+		Sessions:  sessionEq,
+		Validator: validator.New(),
+	}
+}
+
+// Inactivity report bounds, in days.
+const (
+	defaultInactiveDays = 90
+	maxInactiveDays     = 3650
+)
+
+// DeactivateStaffInput defines the payload for deactivating a staff member.
+type DeactivateStaffInput struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// Deactivate deactivates a staff member and revokes all of their sessions in the organization.
+// Requires the "staff.manage" permission.
+// @Summary Deactivate staff
+// @Description Marks a staff member inactive and immediately revokes every session they hold in the caller's organization.
+// @Tags staff
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Staff ID"
+// @Param input body DeactivateStaffInput false "Deactivation reason"
+// @Success 200 {object} response.Response{data=map[string]interface{}} "Staff deactivated"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden, or the target is an admin and the caller is not"
+// @Failure 404 {object} response.Response "Not Found"
+// @Failure 409 {object} response.Response "The target is the owner or the last admin"
+// @Router /staff/{id}/deactivate [post]
+func (h *StaffHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	var input DeactivateStaffInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	// 1. Admins cannot lock themselves out, and only admins act on admins
+	target, ok := h.target(w, r)
+	if !ok {
+		return
+	}
+	if target.UserID == claims.UserID {
+		response.Error(w, http.StatusBadRequest, "You cannot deactivate yourself")
+		return
+	}
+
+	// 2. Deactivate, keeping the owner and at least one admin
+	staff, err := h.StaffRepo.DeactivateStaff(r.Context(), claims.OrgID, target.ID, input.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrStaffNotFound):
+			response.Error(w, http.StatusNotFound, "Staff not found")
+		case errors.Is(err, repository.ErrStaffOwner):
+			response.Error(w, http.StatusConflict, "The organization's owner cannot be deactivated")
+		case errors.Is(err, repository.ErrLastAdmin):
+			response.Error(w, http.StatusConflict, "The organization's last admin cannot be deactivated")
+		default:
+			response.Error(w, http.StatusInternalServerError, "Failed to deactivate staff")
+		}
+		return
+	}
+
+	// 3. Kill their sessions in this organization
+	revoked, err := h.Sessions.RevokeUserOrgSessions(r.Context(), staff.UserID, staff.OrganizationID, "staff_deactivated")
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"staff":            staff,
+		"revoked_sessions": revoked,
+	})
+}
+
+// Unlock clears the login lockout of a staff member's account.
+// Requires the "staff.manage" permission.
+// @Summary Unlock staff account
+// @Description Resets the failed login counter of a staff member and lifts any temporary lockout.
+// @Tags staff
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Staff ID"
+// @Success 200 {object} response.Response{data=map[string]string} "Account unlocked"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden, or the target is an admin and the caller is not"
+// @Failure 404 {object} response.Response "Not Found"
+// @Router /staff/{id}/unlock [post]
+func (h *StaffHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	// 1. The account must belong to staff of the caller's organization
+	target, ok := h.target(w, r)
+	if !ok {
+		return
+	}
+
+	// 2. Unlock
+	if err := h.UserRepo.UnlockUser(r.Context(), target.UserID); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			response.Error(w, http.StatusNotFound, "Staff not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to unlock account")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{"message": "Account unlocked"})
+}
+
+// target loads the staff member {id} of the caller's organization, answering 404 if there is none
+// and 403 if it is an admin and the caller is not.
+func (h *StaffHandler) target(w http.ResponseWriter, r *http.Request) (*repository.Staff, bool) {
+	claims := auth.MustClaims(r.Context())
+
+	id := chi.URLParam(r, "id")
+	if h.Validator.Var(id, "uuid") != nil {
+		response.Error(w, http.StatusNotFound, "Staff not found")
+		return nil, false
+	}
+
+	target, err := h.StaffRepo.GetStaffByID(r.Context(), claims.OrgID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			response.Error(w, http.StatusNotFound, "Staff not found")
+			return nil, false
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to load staff")
+		return nil, false
+	}
+	if target.Role == authz.RoleAdmin && claims.Role != authz.RoleAdmin {
+		response.Error(w, http.StatusForbidden, "Only admins can manage admins")
+		return nil, false
+	}
+	return target, true
+}
+
+// Inactive reports the staff of the caller's organization who have not used their account for a number of days.
+// Requires the "staff.manage" permission.
+// @Summary Inactive staff report
+// @Description Lists active staff without any activity for the given number of days, least recently active first.
+// @Description Staff who never signed in count from when they joined. Feed the ids to POST /staff/{id}/deactivate to deactivate them.
+// @Tags staff
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Days without activity (default 90)" minimum(1) maximum(3650)
+// @Success 200 {object} response.Response{data=map[string]interface{}} "Inactive staff"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Forbidden"
+// @Failure 422 {object} response.Response "Invalid days"
+// @Router /staff/inactive [get]
+func (h *StaffHandler) Inactive(w http.ResponseWriter, r *http.Request) {
+	claims := auth.MustClaims(r.Context())
+
+	days := defaultInactiveDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxInactiveDays {
+			response.FieldError(w, "days", "range")
+			return
+		}
+		days = n
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	staff, err := h.StaffRepo.ListInactiveStaff(r.Context(), claims.OrgID, since)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to load inactive staff")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"days":           days,
+		"inactive_since": since,
+		"staff":          staff,
+	})
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/repository"
+)
+
+// TestUnlockIntegration verifies that an admin can lift the lockout of a staff member.
+func TestUnlockIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	authHandler := newTestAuthHandler(db)
+	staffHandler := NewStaffHandler(authHandler.StaffRepo, authHandler.UserRepo, authHandler.Sessions)
+
+	// 1. Admin with their own org, and a second user added to it as staff
+	admin, err := authHandler.UserRepo.GetUserByEmail(ctx, registerTestUser(t, authHandler, "unlock-admin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orgID string
+	if err := db.Pool.QueryRow(ctx, `SELECT organization_id FROM staff WHERE user_id = $1`, admin.ID).Scan(&orgID); err != nil {
+		t.Fatal(err)
+	}
+
+	email := registerTestUser(t, authHandler, "unlock-target")
+	target, err := authHandler.UserRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	staff := &repository.Staff{
+		OrganizationID: orgID,
+		UserID:         target.ID,
+		Role:           "staff",
+		Permissions:    repository.DefaultPermissions(),
+	}
+	if err := authHandler.StaffRepo.CreateStaff(ctx, staff); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2. Lock the target out
+	for i := 0; i < 3; i++ {
+		attemptLogin(authHandler, email, "WrongPass123!")
+	}
+	if rr := attemptLogin(authHandler, email, "TestPass123!"); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected target to be locked, got %d", rr.Code)
+	}
+
+	// 3. Unlock as the admin
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", staff.ID)
+	req := httptest.NewRequest("POST", "/staff/"+staff.ID+"/unlock", nil)
+	reqCtx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	reqCtx = auth.WithClaims(reqCtx, &auth.Claims{UserID: admin.ID, OrgID: orgID, Role: "admin"})
+	rr := httptest.NewRecorder()
+	staffHandler.Unlock(rr, req.WithContext(reqCtx))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+
+	// 4. The correct password works again
+	loginTestUser(t, authHandler, email)
+}
+
+func TestStaff_InvalidID(t *testing.T) {
+	h := NewStaffHandler(nil, nil, nil) // Refused before the database
+	claims := &auth.Claims{UserID: "admin", OrgID: "org-1", Role: "admin"}
+
+	for name, fn := range map[string]http.HandlerFunc{"Deactivate": h.Deactivate, "Unlock": h.Unlock} {
+		t.Run(name, func(t *testing.T) {
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "not-a-uuid")
+			req := httptest.NewRequest("POST", "/staff/not-a-uuid", http.NoBody)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			rr := httptest.NewRecorder()
+			fn(rr, req.WithContext(auth.WithClaims(ctx, claims)))
+
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("Expected 404, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+// TestDeactivateIntegration verifies that staff managers can't strip an organization of its administration.
+func TestDeactivateIntegration(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	authHandler := newTestAuthHandler(db)
+	staffHandler := NewStaffHandler(authHandler.StaffRepo, authHandler.UserRepo, authHandler.Sessions)
+
+	// 1. The owner, a second admin and a staff manager
+	owner, err := authHandler.UserRepo.GetUserByEmail(ctx, registerTestUser(t, authHandler, "deactivate-owner"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orgID, ownerStaffID string
+	if err := db.Pool.QueryRow(ctx, `SELECT organization_id, id FROM staff WHERE user_id = $1`, owner.ID).Scan(&orgID, &ownerStaffID); err != nil {
+		t.Fatal(err)
+	}
+	join := func(name, role string) *repository.Staff {
+		user, err := authHandler.UserRepo.GetUserByEmail(ctx, registerTestUser(t, authHandler, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := &repository.Staff{OrganizationID: orgID, UserID: user.ID, Role: role, Permissions: repository.DefaultPermissions()}
+		if err := authHandler.StaffRepo.CreateStaff(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	admin := join("deactivate-admin", "admin")
+	manager := join("deactivate-manager", "staff")
+
+	deactivate := func(caller *repository.Staff, id string) int {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		req := httptest.NewRequest("POST", "/staff/"+id+"/deactivate", http.NoBody)
+		reqCtx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		reqCtx = auth.WithClaims(reqCtx, &auth.Claims{UserID: caller.UserID, OrgID: orgID, Role: caller.Role})
+		rr := httptest.NewRecorder()
+		staffHandler.Deactivate(rr, req.WithContext(reqCtx))
+		return rr.Code
+	}
+
+	// 2. Only admins act on admins
+	if code := deactivate(manager, admin.ID); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a non-admin deactivating an admin, got %d", code)
+	}
+
+	// 3. The owner stays
+	if code := deactivate(admin, ownerStaffID); code != http.StatusConflict {
+		t.Errorf("Expected 409 for the owner, got %d", code)
+	}
+
+	// 4. Admins can deactivate each other, and staff
+	if code := deactivate(admin, manager.ID); code != http.StatusOK {
+		t.Errorf("Expected 200 for staff, got %d", code)
+	}
+}
@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/off-by-2/sal/internal/auth"
+	"github.com/off-by-2/sal/internal/repository"
+	"github.com/off-by-2/sal/internal/response"
+)
+
+// twoFactorIssuer is the account issuer shown in authenticator apps.
+const twoFactorIssuer = "Sal"
+
+// TwoFactorVerifyInput defines the payload for the second login step.
+// Exactly one of Code or RecoveryCode is required.
+type TwoFactorVerifyInput struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required_without=RecoveryCode,excluded_with=RecoveryCode"`
+	RecoveryCode   string `json:"recovery_code" validate:"max=32"`
+	DeviceID       string `json:"device_id" validate:"max=255"`
+}
+
+// TwoFactorConfirmInput defines the payload for confirming two-factor enrolment.
+type TwoFactorConfirmInput struct {
+	Code     string `json:"code" validate:"required,len=6,numeric"`
+	DeviceID string `json:"device_id" validate:"max=255"` // Used when confirming completes a login
+}
+
+// VerifyTwoFactor completes a login with a TOTP or recovery code.
+// @Summary Verify second factor
+// @Description Exchanges the challenge token returned by POST /auth/login (mfa_required) and a TOTP code or
+// @Description a single-use recovery code for an access/refresh token pair.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body TwoFactorVerifyInput true "Challenge and code"
+// @Success 200 {object} response.Response{data=map[string]string} "Tokens"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 429 {object} response.Response "Account temporarily locked"
+// @Router /auth/2fa/verify [post]
+func (h *AuthHandler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var input TwoFactorVerifyInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	// 1. Check Challenge
+	claims, err := auth.ParseChallengeToken(input.ChallengeToken, auth.PurposeMFA, h.Keys)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "Invalid or expired challenge")
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(r.Context(), claims.UserID)
+	if err != nil || !user.IsActive || !user.TwoFactorEnabled || user.TwoFactorSecret == nil {
+		response.Error(w, http.StatusUnauthorized, "Invalid or expired challenge")
+		return
+	}
+
+	// 2. Check Lockout (failed codes count like failed passwords)
+	if user.IsLocked() {
+		lockedError(w, *user.LockedUntil)
+		return
+	}
+
+	// 3. Check Code
+	if !h.checkSecondFactor(r, user, input.Code, input.RecoveryCode) {
+		lockedUntil, err := h.UserRepo.RecordFailedLogin(r.Context(), user.ID, h.Lockout.LockDuration)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to record login attempt")
+			return
+		}
+		if lockedUntil != nil {
+			lockedError(w, *lockedUntil)
+			return
+		}
+		response.Error(w, http.StatusUnauthorized, "Invalid two-factor code")
+		return
+	}
+
+	// 4. Create Session and Tokens
+	tokens, err := h.startSession(w, r, user.ID, claims.OrgID, claims.Role, input.DeviceID, auth.WithAMR(claims.AMR, auth.AMROTP))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, tokens)
+}
+
+// EnrollTwoFactor starts two-factor enrolment by generating a new TOTP secret.
+// @Summary Start two-factor enrolment
+// @Description Generates a TOTP secret and otpauth:// URI for an authenticator app. The secret is only active once confirmed.
+// @Description Accepts an access token, or the challenge token returned by POST /auth/login (mfa_enrollment_required).
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=map[string]string} "Secret and URI"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Not allowed while impersonating"
+// @Failure 409 {object} response.Response "Already enabled"
+// @Router /auth/2fa/enroll [post]
+func (h *AuthHandler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	claims, ok := h.twoFactorCaller(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(r.Context(), claims.UserID)
+	if err != nil || !user.IsActive {
+		response.Error(w, http.StatusUnauthorized, "Account is inactive")
+		return
+	}
+	if user.TwoFactorEnabled {
+		response.Error(w, http.StatusConflict, "Two-factor authentication is already enabled")
+		return
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to generate secret")
+		return
+	}
+
+	if err := h.UserRepo.SetPendingTwoFactorSecret(r.Context(), user.ID, secret); err != nil {
+		if errors.Is(err, repository.ErrTwoFactorAlreadyEnabled) {
+			response.Error(w, http.StatusConflict, "Two-factor authentication is already enabled")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to store secret")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{
+		"secret":      secret,
+		"otpauth_uri": auth.TOTPURI(twoFactorIssuer, user.Email, secret),
+	})
+}
+
+// ConfirmTwoFactor enables two-factor authentication once the first code from the authenticator app checks out.
+// @Summary Confirm two-factor enrolment
+// @Description Verifies the first TOTP code, enables two-factor authentication and returns single-use recovery codes.
+// @Description The recovery codes are only shown once. When called with an enrolment challenge token, the login is
+// @Description completed and tokens are returned as well.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body TwoFactorConfirmInput true "First code"
+// @Success 200 {object} response.Response{data=map[string]interface{}} "Recovery codes (and tokens)"
+// @Failure 400 {object} response.Response "Invalid code"
+// @Failure 401 {object} response.Response "Unauthorized"
+// @Failure 403 {object} response.Response "Not allowed while impersonating"
+// @Failure 409 {object} response.Response "Already enabled"
+// @Router /auth/2fa/confirm [post]
+func (h *AuthHandler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	claims, ok := h.twoFactorCaller(w, r)
+	if !ok {
+		return
+	}
+
+	var input TwoFactorConfirmInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Validator.Struct(input); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(r.Context(), claims.UserID)
+	if err != nil || !user.IsActive {
+		response.Error(w, http.StatusUnauthorized, "Account is inactive")
+		return
+	}
+	if user.TwoFactorEnabled {
+		response.Error(w, http.StatusConflict, "Two-factor authentication is already enabled")
+		return
+	}
+	if user.TwoFactorSecret == nil {
+		response.Error(w, http.StatusBadRequest, "Two-factor enrolment has not been started")
+		return
+	}
+
+	// 1. Check Code
+	step, ok := auth.ValidateTOTP(*user.TwoFactorSecret, input.Code, time.Now())
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "Invalid two-factor code")
+		return
+	}
+
+	// 2. Enable with fresh recovery codes
+	codes, err := auth.NewRecoveryCodes()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to generate recovery codes")
+		return
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = auth.HashRecoveryCode(c)
+	}
+
+	if err := h.UserRepo.EnableTwoFactor(r.Context(), user.ID, step, hashes); err != nil {
+		if errors.Is(err, repository.ErrTwoFactorAlreadyEnabled) {
+			response.Error(w, http.StatusConflict, "Two-factor authentication is already enabled")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to enable two-factor authentication")
+		return
+	}
+
+	data := map[string]interface{}{"recovery_codes": codes}
+
+	// 3. Enrolment during login: password and code are both proven, so complete the login
+	if claims.Purpose == auth.PurposeMFAEnroll {
+		tokens, err := h.startSession(w, r, user.ID, claims.OrgID, claims.Role, input.DeviceID, auth.WithAMR(claims.AMR, auth.AMROTP))
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to create session")
+			return
+		}
+		for k, v := range tokens {
+			data[k] = v
+		}
+	}
+
+	response.JSON(w, http.StatusOK, data)
+}
+
+// twoFactorChallenge responds with a challenge token instead of a token pair.
+func (h *AuthHandler) twoFactorChallenge(w http.ResponseWriter, claims auth.Claims, purpose string, memberships []repository.Membership) {
+	token, err := auth.SignChallengeToken(claims, purpose, h.Keys)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Token generation failed")
+		return
+	}
+
+	flag := "mfa_required"
+	if purpose == auth.PurposeMFAEnroll {
+		flag = "mfa_enrollment_required"
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		flag:              true,
+		"challenge_token": token,
+		"expires_in":      int(auth.ChallengeTokenDuration.Seconds()),
+		"organization_id": claims.OrgID,
+		"memberships":     memberships,
+	})
+}
+
+// AuthenticateEnrollment is the middleware of the two-factor enrolment routes. Staff of an organization requiring
+// two-factor authentication cannot obtain an access token before enrolling, so an enrolment challenge token is
+// accepted as is; any other token goes through authenticated, e.g. Authenticate followed by the write audits.
+func (h *AuthHandler) AuthenticateEnrollment(authenticated func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		other := authenticated(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if claims, err := auth.ParseChallengeToken(auth.BearerToken(r), auth.PurposeMFAEnroll, h.Keys); err == nil {
+				next.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
+				return
+			}
+			other.ServeHTTP(w, r)
+		})
+	}
+}
+
+// twoFactorCaller returns the caller of an enrolment request, authenticated by AuthenticateEnrollment.
+// Impersonators are refused: enrolling would take over the second factor of the impersonated account.
+// It writes an error response and returns false otherwise.
+func (h *AuthHandler) twoFactorCaller(w http.ResponseWriter, r *http.Request) (*auth.Claims, bool) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Missing bearer token")
+		return nil, false
+	}
+	if claims.Impersonated() {
+		response.Error(w, http.StatusForbidden, auth.ImpersonationDeniedMessage)
+		return nil, false
+	}
+	return claims, true
+}
+
+// checkSecondFactor validates a TOTP code (rejecting replays) or consumes a recovery code.
+func (h *AuthHandler) checkSecondFactor(r *http.Request, user *repository.User, code, recoveryCode string) bool {
+	if recoveryCode != "" {
+		return h.UserRepo.UseRecoveryCode(r.Context(), user.ID, auth.HashRecoveryCode(recoveryCode)) == nil
+	}
+
+	step, ok := auth.ValidateTOTP(*user.TwoFactorSecret, code, time.Now())
+	if !ok {
+		return false
+	}
+	return h.UserRepo.UseTOTPStep(r.Context(), user.ID, step) == nil
+}
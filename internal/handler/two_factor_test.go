@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/off-by-2/sal/internal/auth"
+)
+
+// postWithBearer posts a JSON body to fn with an Authorization header and returns the decoded response.
+func postWithBearer(t *testing.T, fn http.HandlerFunc, token string, body interface{}) (int, APIResponse) {
+	t.Helper()
+
+	raw, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(raw))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	fn(rr, req)
+
+	var resp APIResponse
+	_ = json.NewDecoder(rr.Body).Decode(&resp)
+	return rr.Code, resp
+}
+
+// enrollment routes fn like the server does, authenticating access tokens without the write audits.
+func enrollment(handler *AuthHandler, fn http.HandlerFunc) http.HandlerFunc {
+	return handler.AuthenticateEnrollment(auth.Authenticate(handler.Verifier))(fn).ServeHTTP
+}
+
+// enrollTwoFactor enrols the caller behind token and returns the TOTP secret and confirm response.
+func enrollTwoFactor(t *testing.T, handler *AuthHandler, token string) (string, APIResponse) {
+	t.Helper()
+
+	code, resp := postWithBearer(t, enrollment(handler, handler.EnrollTwoFactor), token, nil)
+	if code != http.StatusOK {
+		t.Fatalf("Enroll returned %d: %v", code, resp.Data)
+	}
+	secret := resp.Data["secret"].(string)
+
+	totp, _ := auth.TOTPCode(secret, auth.TOTPStep(time.Now()))
+	code, resp = postWithBearer(t, enrollment(handler, handler.ConfirmTwoFactor), token, map[string]string{"code": totp})
+	if code != http.StatusOK {
+		t.Fatalf("Confirm returned %d: %v", code, resp.Data)
+	}
+	return secret, resp
+}
+
+// TestTwoFactorLoginFlow enrols a user, then logs in with a challenge and a recovery code.
+func TestTwoFactorLoginFlow(t *testing.T) {
+	db := setupTestDB(t)
+	handler := newTestAuthHandler(db)
+	email := registerTestUser(t, handler, "2fa")
+
+	// 1. Enrol with a regular access token
+	login := loginTestUser(t, handler, email)
+	_, confirm := enrollTwoFactor(t, handler, login.Data["access_token"].(string))
+
+	codes, ok := confirm.Data["recovery_codes"].([]interface{})
+	if !ok || len(codes) != auth.RecoveryCodeCount {
+		t.Fatalf("Expected %d recovery codes, got %v", auth.RecoveryCodeCount, confirm.Data["recovery_codes"])
+	}
+	if _, ok := confirm.Data["access_token"]; ok {
+		t.Error("Expected no tokens when enrolling with an access token")
+	}
+
+	// 2. Password login now only yields a challenge
+	challenge := loginTestUser(t, handler, email)
+	if challenge.Data["mfa_required"] != true {
+		t.Fatalf("Expected mfa_required, got %v", challenge.Data)
+	}
+	if _, ok := challenge.Data["access_token"]; ok {
+		t.Fatal("Expected no access token before the second factor")
+	}
+	challengeToken := challenge.Data["challenge_token"].(string)
+
+	// The challenge token is not an access token
+	if code, _ := postWithBearer(t, handler.LogoutAll, challengeToken, nil); code != http.StatusUnauthorized {
+		t.Errorf("Expected challenge token to be rejected as access token, got %d", code)
+	}
+
+	// 3. Wrong code
+	code, _ := postWithBearer(t, handler.VerifyTwoFactor, "", map[string]string{
+		"challenge_token": challengeToken,
+		"code":            "000000",
+	})
+	if code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for wrong code, got %d", code)
+	}
+
+	// 4. Recovery code works once
+	verify := map[string]string{"challenge_token": challengeToken, "recovery_code": codes[0].(string)}
+	code, resp := postWithBearer(t, handler.VerifyTwoFactor, "", verify)
+	if code != http.StatusOK || resp.Data["access_token"] == nil {
+		t.Fatalf("Expected tokens for recovery code, got %d: %v", code, resp.Data)
+	}
+	if code, _ := postWithBearer(t, handler.VerifyTwoFactor, "", verify); code != http.StatusUnauthorized {
+		t.Errorf("Expected used recovery code to be rejected, got %d", code)
+	}
+}
+
+// TestTwoFactorEnrollment_RefusesImpersonation verifies that an impersonator can't take over the second factor.
+func TestTwoFactorEnrollment_RefusesImpersonation(t *testing.T) {
+	db := setupTestDB(t)
+	handler := newTestAuthHandler(db)
+	email := registerTestUser(t, handler, "2fa-impersonated")
+
+	claims, err := handler.Verifier.Verify(context.Background(), loginTestUser(t, handler, email).Data["access_token"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := auth.SignImpersonationToken(*claims, "support-admin", time.Minute, handler.Keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, fn := range map[string]http.HandlerFunc{"Enroll": handler.EnrollTwoFactor, "Confirm": handler.ConfirmTwoFactor} {
+		if code, _ := postWithBearer(t, enrollment(handler, fn), token, map[string]string{"code": "000000"}); code != http.StatusForbidden {
+			t.Errorf("%s: expected 403 for an impersonation token, got %d", name, code)
+		}
+	}
+}
+
+// TestTwoFactorRequiredByOrg verifies that staff of a 2FA-required org must enrol before getting tokens.
+func TestTwoFactorRequiredByOrg(t *testing.T) {
+	db := setupTestDB(t)
+	handler := newTestAuthHandler(db)
+	email := registerTestUser(t, handler, "2fa-required")
+
+	_, err := db.Pool.Exec(context.Background(), `
+		UPDATE organizations SET settings = jsonb_set(settings, '{require_two_factor}', 'true')
+		WHERE owner_user_id = (SELECT id FROM users WHERE email = $1)`,
+		email,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 1. Login yields an enrolment challenge only
+	login := loginTestUser(t, handler, email)
+	if login.Data["mfa_enrollment_required"] != true {
+		t.Fatalf("Expected mfa_enrollment_required, got %v", login.Data)
+	}
+	enrollToken := login.Data["challenge_token"].(string)
+
+	// 2. Enrolling with the challenge completes the login
+	_, confirm := enrollTwoFactor(t, handler, enrollToken)
+	if confirm.Data["access_token"] == nil {
+		t.Errorf("Expected tokens after enrolment, got %v", confirm.Data)
+	}
+}
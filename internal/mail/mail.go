@@ -0,0 +1,126 @@
+// Package mail delivers transactional emails (verification, password reset, invitations).
+//
+// Handlers depend on the Mailer interface; the implementation is chosen by configuration.
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Options configures New.
+type Options struct {
+	Driver       string // "file" or "smtp"
+	From         string
+	Dir          string // Output directory of the file driver
+	SMTPAddr     string // host:port of the smtp driver
+	SMTPUsername string // Optional; enables PLAIN auth
+	SMTPPassword string
+}
+
+// New creates the Mailer selected by opts.Driver.
+func New(opts Options) (Mailer, error) {
+	switch opts.Driver {
+	case "file":
+		return &FileMailer{Dir: opts.Dir, From: opts.From}, nil
+	case "smtp":
+		return &SMTPMailer{Addr: opts.SMTPAddr, From: opts.From, Username: opts.SMTPUsername, Password: opts.SMTPPassword}, nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", opts.Driver)
+	}
+}
+
+// FileMailer writes every message as an .eml file into Dir instead of sending it.
+// It is meant for local development and tests.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// Send writes msg to a new file in m.Dir.
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+	if err := os.MkdirAll(m.Dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to name mail file: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	if err := os.WriteFile(filepath.Join(m.Dir, name), compose(m.From, msg), 0o600); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	return nil
+}
+
+// SMTPMailer sends messages through an SMTP server.
+type SMTPMailer struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+// Send delivers msg through the SMTP server.
+func (m *SMTPMailer) Send(_ context.Context, msg Message) error {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid smtp address: %w", err)
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	if err := smtp.SendMail(m.Addr, auth, from.Address, []string{msg.To}, compose(m.From, msg)); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
+
+// compose renders msg as an RFC 5322 message.
+func compose(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + sanitizeHeader(msg.To) + "\r\n")
+	b.WriteString("Subject: " + sanitizeHeader(msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// sanitizeHeader strips line breaks so a header value cannot inject further headers.
+func sanitizeHeader(v string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(v)
+}
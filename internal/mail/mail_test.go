@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m, err := New(Options{Driver: "file", Dir: dir, From: "Sal <no-reply@localhost>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Send(context.Background(), Message{
+		To:      "jane@example.com",
+		Subject: "Hello\r\nBcc: evil@example.com",
+		Body:    "Line 1\nLine 2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 mail file, got %d", len(files))
+	}
+	raw, _ := os.ReadFile(files[0])
+	content := string(raw)
+
+	if !strings.Contains(content, "To: jane@example.com\r\n") {
+		t.Errorf("Missing recipient: %q", content)
+	}
+	if strings.Contains(content, "\r\nBcc:") {
+		t.Errorf("Header injection was not stripped: %q", content)
+	}
+	if !strings.HasSuffix(content, "Line 1\r\nLine 2") {
+		t.Errorf("Unexpected body: %q", content)
+	}
+}
+
+func TestNew_UnknownDriver(t *testing.T) {
+	if _, err := New(Options{Driver: "carrier-pigeon"}); err == nil {
+		t.Error("Expected error for unknown driver")
+	}
+}
@@ -0,0 +1,380 @@
+// Package oidc implements the client side of OpenID Connect sign-in (authorization code flow with PKCE)
+// for the social providers (Google, Apple, Microsoft).
+//
+// Provider endpoints are read from the issuer's discovery document and ID tokens are verified
+// against the issuer's JWKS. Everything goes over HTTP, so tests can point a Provider at a local
+// fake issuer (see package oidctest).
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Well-known issuers of the supported providers.
+const (
+	GoogleIssuer = "https://accounts.google.com"
+	AppleIssuer  = "https://appleid.apple.com"
+	// MicrosoftIssuer is formatted with a tenant ("common", "organizations" or a tenant ID).
+	MicrosoftIssuer = "https://login.microsoftonline.com/%s/v2.0"
+)
+
+// FlowDuration is how long a user has to complete the sign-in at the provider.
+const FlowDuration = 10 * time.Minute
+
+// discoveryTTL is how long discovery documents and keys are cached.
+const discoveryTTL = time.Hour
+
+var (
+	// ErrInvalidIDToken is returned when an ID token fails verification.
+	ErrInvalidIDToken = errors.New("invalid id token")
+	// ErrInvalidFlow is returned when the state of a sign-in flow is missing, expired or does not match.
+	ErrInvalidFlow = errors.New("invalid sign-in flow")
+)
+
+// Config describes an OIDC client registration with one provider.
+type Config struct {
+	Name         string // Provider name, matches the auth_provider_type enum (e.g. "google")
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string     // Defaults to openid, email, profile
+	AuthParams   url.Values   // Extra authorization request parameters (e.g. Apple's response_mode)
+	HTTPClient   *http.Client // Defaults to a client with a 10s timeout
+}
+
+// Identity is the verified subject of an ID token.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+// discovery is the subset of the discovery document used here.
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider signs users in with one OIDC provider.
+// Discovery is lazy, so creating a Provider needs no network access.
+type Provider struct {
+	cfg Config
+
+	mu        sync.Mutex
+	meta      *discovery
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// NewProvider creates a Provider for cfg.
+func NewProvider(cfg Config) *Provider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{cfg: cfg}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthRequest holds the per-sign-in secrets: state (CSRF), nonce (ID token replay) and the PKCE verifier.
+type AuthRequest struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// NewAuthRequest generates fresh secrets for a sign-in with provider.
+func NewAuthRequest(provider string) (AuthRequest, error) {
+	var values [3]string
+	for i := range values {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return AuthRequest{}, fmt.Errorf("failed to generate auth request: %w", err)
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return AuthRequest{Provider: provider, State: values[0], Nonce: values[1], Verifier: values[2]}, nil
+}
+
+// flowClaims carries an AuthRequest between the start and the callback of a sign-in.
+type flowClaims struct {
+	AuthRequest
+	jwt.RegisteredClaims
+}
+
+// Seal signs the request so it can be kept by the client (in a cookie) until the callback.
+func (r AuthRequest) Seal(secret string) (string, error) {
+	claims := flowClaims{
+		AuthRequest: r,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(FlowDuration)),
+			Subject:   "oidc_flow",
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// OpenAuthRequest checks a sealed request and that it belongs to provider and state.
+func OpenAuthRequest(sealed, secret, provider, state string) (AuthRequest, error) {
+	var claims flowClaims
+	_, err := jwt.ParseWithClaims(sealed, &claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired(), jwt.WithSubject("oidc_flow"))
+	if err != nil {
+		return AuthRequest{}, ErrInvalidFlow
+	}
+
+	if claims.Provider != provider || subtle.ConstantTimeCompare([]byte(claims.State), []byte(state)) != 1 {
+		return AuthRequest{}, ErrInvalidFlow
+	}
+	return claims.AuthRequest, nil
+}
+
+// AuthCodeURL returns the provider URL the user is sent to.
+func (p *Provider) AuthCodeURL(ctx context.Context, req AuthRequest) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(req.Verifier))
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", req.State)
+	q.Set("nonce", req.Nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	for k, v := range p.cfg.AuthParams {
+		q[k] = v
+	}
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// SignIn exchanges an authorization code and returns the verified identity.
+func (p *Provider) SignIn(ctx context.Context, code string, req AuthRequest) (*Identity, error) {
+	rawIDToken, err := p.exchange(ctx, code, req.Verifier)
+	if err != nil {
+		return nil, err
+	}
+	return p.Verify(ctx, rawIDToken, req.Nonce)
+}
+
+// exchange redeems an authorization code at the token endpoint and returns the raw ID token.
+func (p *Provider) exchange(ctx context.Context, code, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("code_verifier", verifier)
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var body struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := p.do(req, &body); err != nil {
+		return "", fmt.Errorf("token exchange failed: %w", err)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("token exchange returned no id_token")
+	}
+	return body.IDToken, nil
+}
+
+// idClaims are the ID token claims used here.
+type idClaims struct {
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+	GivenName     string   `json:"given_name"`
+	FamilyName    string   `json:"family_name"`
+	TenantID      string   `json:"tid"` // Microsoft only
+	jwt.RegisteredClaims
+}
+
+// flexBool accepts both true and "true"; Apple sends email_verified as a string.
+type flexBool bool
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	*b = flexBool(s == "true")
+	return nil
+}
+
+// Verify checks the signature, issuer, audience, expiry and nonce of an ID token.
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*Identity, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims idClaims
+	_, err = jwt.ParseWithClaims(rawIDToken, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	// Multi-tenant Microsoft endpoints advertise "{tenantid}" in their issuer
+	issuer := strings.ReplaceAll(meta.Issuer, "{tenantid}", claims.TenantID)
+	if claims.Issuer != issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, claims.Issuer)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	return &Identity{
+		Provider:      p.cfg.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+	}, nil
+}
+
+// discover returns the cached discovery document, fetching it when missing or stale.
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.meta != nil && time.Since(p.fetchedAt) < discoveryTTL {
+		return p.meta, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build discovery request: %w", err)
+	}
+	var meta discovery
+	if err := p.do(req, &meta); err != nil {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document of %s is incomplete", p.cfg.Issuer)
+	}
+
+	p.meta = &meta
+	p.keys = nil
+	p.fetchedAt = time.Now()
+	return p.meta, nil
+}
+
+// key returns the verification key with the given kid, refetching the JWKS once if it is unknown
+// (providers rotate keys).
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.JWKSURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build jwks request: %w", err)
+	}
+	var set JWKSet
+	if err := p.do(req, &set); err != nil {
+		return nil, fmt.Errorf("jwks fetch failed: %w", err)
+	}
+	keys, err := set.PublicKeys()
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// do sends req and decodes a JSON response into out.
+func (p *Provider) do(req *http.Request, out interface{}) error {
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,161 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/off-by-2/sal/internal/oidc"
+	"github.com/off-by-2/sal/internal/oidc/oidctest"
+)
+
+var testUser = oidctest.User{
+	Subject:       "1234567890",
+	Email:         "Jane@Example.com",
+	EmailVerified: true,
+	GivenName:     "Jane",
+	FamilyName:    "Doe",
+}
+
+func TestSignIn(t *testing.T) {
+	ctx := context.Background()
+	issuer := oidctest.New(t, "client-1")
+	p := oidc.NewProvider(issuer.Config("google", "http://app.test/callback"))
+
+	req, err := oidc.NewAuthRequest("google")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := p.AuthCodeURL(ctx, req)
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+
+	q, _ := url.Parse(authURL)
+	if q.Query().Get("code_challenge") == req.Verifier {
+		t.Error("PKCE challenge must not be the plain verifier")
+	}
+
+	code, state, err := issuer.Authorize(authURL, testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != req.State {
+		t.Errorf("Expected state to round-trip, got %q", state)
+	}
+
+	id, err := p.SignIn(ctx, code, req)
+	if err != nil {
+		t.Fatalf("SignIn failed: %v", err)
+	}
+	if id.Provider != "google" || id.Subject != testUser.Subject || id.Email != testUser.Email || !id.EmailVerified {
+		t.Errorf("Unexpected identity: %+v", id)
+	}
+
+	// Codes are single use
+	if _, err := p.SignIn(ctx, code, req); err == nil {
+		t.Error("Expected a redeemed code to be rejected")
+	}
+}
+
+func TestSignInWrongVerifier(t *testing.T) {
+	ctx := context.Background()
+	issuer := oidctest.New(t, "client-1")
+	p := oidc.NewProvider(issuer.Config("google", "http://app.test/callback"))
+
+	req, _ := oidc.NewAuthRequest("google")
+	authURL, err := p.AuthCodeURL(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _, _ := issuer.Authorize(authURL, testUser)
+
+	other, _ := oidc.NewAuthRequest("google")
+	req.Verifier = other.Verifier
+	if _, err := p.SignIn(ctx, code, req); err == nil {
+		t.Error("Expected a wrong PKCE verifier to be rejected")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	issuer := oidctest.New(t, "client-1")
+	p := oidc.NewProvider(issuer.Config("apple", "http://app.test/callback"))
+
+	tests := []struct {
+		name   string
+		mutate func(c map[string]interface{})
+		nonce  string
+		valid  bool
+	}{
+		{"valid", func(c map[string]interface{}) {}, "n-1", true},
+		{"string email_verified", func(c map[string]interface{}) { c["email_verified"] = "true" }, "n-1", true},
+		{"wrong nonce", func(c map[string]interface{}) {}, "n-2", false},
+		{"wrong audience", func(c map[string]interface{}) { c["aud"] = "someone-else" }, "n-1", false},
+		{"wrong issuer", func(c map[string]interface{}) { c["iss"] = "https://evil.test" }, "n-1", false},
+		{"expired", func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, "n-1", false},
+		{"missing subject", func(c map[string]interface{}) { delete(c, "sub") }, "n-1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := issuer.IDToken(testUser, "n-1")
+			tt.mutate(claims)
+
+			id, err := p.Verify(ctx, issuer.Sign(claims), tt.nonce)
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("Expected valid token, got %v", err)
+				}
+				if !id.EmailVerified {
+					t.Error("Expected email to be verified")
+				}
+				return
+			}
+			if !errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Errorf("Expected ErrInvalidIDToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	issuer := oidctest.New(t, "client-1")
+	p := oidc.NewProvider(issuer.Config("microsoft", "http://app.test/callback"))
+
+	if _, err := p.Verify(ctx, issuer.Sign(issuer.IDToken(testUser, "n")), "n"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A token signed with a new key triggers a JWKS refetch
+	issuer.RotateKey()
+	if _, err := p.Verify(ctx, issuer.Sign(issuer.IDToken(testUser, "n")), "n"); err != nil {
+		t.Errorf("Expected rotated key to be picked up, got %v", err)
+	}
+}
+
+func TestAuthRequestSeal(t *testing.T) {
+	req, _ := oidc.NewAuthRequest("google")
+	sealed, err := req.Seal("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := oidc.OpenAuthRequest(sealed, "secret", "google", req.State)
+	if err != nil || got != req {
+		t.Fatalf("Expected request to round-trip, got %+v, %v", got, err)
+	}
+
+	if _, err := oidc.OpenAuthRequest(sealed, "other", "google", req.State); !errors.Is(err, oidc.ErrInvalidFlow) {
+		t.Error("Expected wrong secret to be rejected")
+	}
+	if _, err := oidc.OpenAuthRequest(sealed, "secret", "apple", req.State); !errors.Is(err, oidc.ErrInvalidFlow) {
+		t.Error("Expected wrong provider to be rejected")
+	}
+	if _, err := oidc.OpenAuthRequest(sealed, "secret", "google", "forged"); !errors.Is(err, oidc.ErrInvalidFlow) {
+		t.Error("Expected wrong state to be rejected")
+	}
+}
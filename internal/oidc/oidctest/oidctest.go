@@ -0,0 +1,211 @@
+// Package oidctest provides a local fake OIDC issuer for tests.
+//
+// The issuer serves discovery, JWKS and token endpoints over httptest. Instead of a browser
+// redirect, tests call Authorize with the authorization URL and the user that "signs in",
+// and get back the code and state the provider would have redirected with.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/off-by-2/sal/internal/oidc"
+)
+
+// User is the account that signs in at the fake issuer.
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+// grant is an authorization code waiting to be redeemed.
+type grant struct {
+	user        User
+	clientID    string
+	redirectURI string
+	nonce       string
+	challenge   string
+}
+
+// Issuer is a fake OIDC provider.
+type Issuer struct {
+	URL      string
+	ClientID string
+
+	srv *httptest.Server
+
+	mu     sync.Mutex
+	key    *rsa.PrivateKey
+	kid    int
+	grants map[string]grant
+}
+
+// New starts an issuer for clientID. It is shut down when the test ends.
+func New(t testing.TB, clientID string) *Issuer {
+	t.Helper()
+
+	i := &Issuer{ClientID: clientID, grants: make(map[string]grant)}
+	i.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", i.discovery)
+	mux.HandleFunc("GET /jwks", i.jwks)
+	mux.HandleFunc("POST /token", i.token)
+
+	i.srv = httptest.NewServer(mux)
+	i.URL = i.srv.URL
+	t.Cleanup(i.srv.Close)
+	return i
+}
+
+// Config returns a client configuration pointing at the issuer.
+func (i *Issuer) Config(name, redirectURL string) oidc.Config {
+	return oidc.Config{
+		Name:        name,
+		Issuer:      i.URL,
+		ClientID:    i.ClientID,
+		RedirectURL: redirectURL,
+		HTTPClient:  i.srv.Client(),
+	}
+}
+
+// RotateKey replaces the signing key, as providers periodically do.
+func (i *Issuer) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.key = key
+	i.kid++
+}
+
+// Authorize plays the user's visit to authURL: it signs in as u and returns the code and state
+// that would be passed to the redirect URL.
+func (i *Issuer) Authorize(authURL string, u User) (code, state string, err error) {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	q := parsed.Query()
+	if q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		return "", "", fmt.Errorf("authorization request is not an S256 PKCE code request")
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	code = base64.RawURLEncoding.EncodeToString(b)
+
+	i.mu.Lock()
+	i.grants[code] = grant{
+		user:        u,
+		clientID:    q.Get("client_id"),
+		redirectURI: q.Get("redirect_uri"),
+		nonce:       q.Get("nonce"),
+		challenge:   q.Get("code_challenge"),
+	}
+	i.mu.Unlock()
+
+	return code, q.Get("state"), nil
+}
+
+// Sign signs arbitrary ID token claims with the current key.
+func (i *Issuer) Sign(claims jwt.MapClaims) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = fmt.Sprint(i.kid)
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+// IDToken returns the claims of a valid ID token for u.
+func (i *Issuer) IDToken(u User, nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            i.URL,
+		"aud":            i.ClientID,
+		"sub":            u.Subject,
+		"email":          u.Email,
+		"email_verified": u.EmailVerified,
+		"given_name":     u.GivenName,
+		"family_name":    u.FamilyName,
+		"nonce":          nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 i.URL,
+		"authorization_endpoint": i.URL + "/authorize",
+		"token_endpoint":         i.URL + "/token",
+		"jwks_uri":               i.URL + "/jwks",
+	})
+}
+
+func (i *Issuer) jwks(w http.ResponseWriter, _ *http.Request) {
+	i.mu.Lock()
+	set := oidc.JWKSet{Keys: []oidc.JWK{oidc.RSAKey(fmt.Sprint(i.kid), &i.key.PublicKey)}}
+	i.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, set)
+}
+
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	// Codes are single use
+	i.mu.Lock()
+	g, ok := i.grants[r.PostForm.Get("code")]
+	delete(i.grants, r.PostForm.Get("code"))
+	i.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	switch {
+	case !ok || r.PostForm.Get("grant_type") != "authorization_code":
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+	case r.PostForm.Get("client_id") != g.clientID || r.PostForm.Get("redirect_uri") != g.redirectURI:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+	case base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+	default:
+		writeJSON(w, http.StatusOK, map[string]string{
+			"token_type": "Bearer",
+			"id_token":   i.Sign(i.IDToken(g.user, g.nonce)),
+		})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
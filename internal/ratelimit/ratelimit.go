@@ -0,0 +1,69 @@
+// Package ratelimit provides a small in-memory rate limiter keyed by caller.
+//
+// Limits are per instance: with several API instances behind a load balancer,
+// a caller gets up to the limit on each of them.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// maxKeys bounds the limiter; idle keys are pruned once it is reached.
+const maxKeys = 10000
+
+// Limiter allows up to limit events per key within a sliding window.
+type Limiter struct {
+	limit  int
+	window time.Duration
+
+	mu     sync.Mutex
+	events map[string][]time.Time
+}
+
+// New creates a Limiter allowing limit events per window.
+func New(limit int, window time.Duration) *Limiter {
+	return &Limiter{limit: limit, window: window, events: make(map[string][]time.Time)}
+}
+
+// Allow records an event for key if it is within the limit.
+// Otherwise it returns false and how long until the next event is allowed.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if len(l.events) >= maxKeys {
+		l.prune(now)
+	}
+
+	recent := l.recent(key, now)
+	if len(recent) >= l.limit {
+		l.events[key] = recent
+		return false, recent[0].Add(l.window).Sub(now)
+	}
+
+	l.events[key] = append(recent, now)
+	return true, 0
+}
+
+// recent returns the events of key still inside the window, oldest first.
+// The caller must hold l.mu.
+func (l *Limiter) recent(key string, now time.Time) []time.Time {
+	events := l.events[key]
+	i := 0
+	for i < len(events) && now.Sub(events[i]) >= l.window {
+		i++
+	}
+	return events[i:]
+}
+
+// prune drops keys without events inside the window.
+// The caller must hold l.mu.
+func (l *Limiter) prune(now time.Time) {
+	for k := range l.events {
+		if len(l.recent(k, now)) == 0 {
+			delete(l.events, k)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := New(2, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("user-1"); !ok {
+			t.Fatalf("Expected event %d to be allowed", i+1)
+		}
+	}
+
+	ok, retryAfter := l.Allow("user-1")
+	if ok {
+		t.Fatal("Expected third event to be limited")
+	}
+	if retryAfter <= 0 || retryAfter > time.Hour {
+		t.Errorf("Unexpected retry after %s", retryAfter)
+	}
+
+	if ok, _ := l.Allow("user-2"); !ok {
+		t.Error("Expected keys to be limited independently")
+	}
+}
+
+func TestLimiter_WindowSlides(t *testing.T) {
+	l := New(1, 20*time.Millisecond)
+
+	if ok, _ := l.Allow("k"); !ok {
+		t.Fatal("Expected first event to be allowed")
+	}
+	if ok, _ := l.Allow("k"); ok {
+		t.Fatal("Expected second event to be limited")
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if ok, _ := l.Allow("k"); !ok {
+		t.Error("Expected event to be allowed after the window")
+	}
+}
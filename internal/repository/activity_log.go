@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/off-by-2/sal/internal/database"
+)
+
+// Activity log actions.
+const (
+	ActionImpersonationStart = "impersonation.start"
+	ActionImpersonationStop  = "impersonation.stop"
+	ActionImpersonationWrite = "impersonation.write"
+	ActionAPIKeyCreate       = "api_key.create"
+	ActionAPIKeyRevoke       = "api_key.revoke"
+	ActionAPIKeyWrite        = "api_key.write"
+	ActionOrgSuspend         = "organization.suspend"
+	ActionOrgReinstate       = "organization.reinstate"
+)
+
+// ActivityEntry represents a row in the activity_log table.
+type ActivityEntry struct {
+	ID             string                 `json:"id"`
+	OrganizationID *string                `json:"organization_id,omitempty"`
+	UserID         *string                `json:"user_id,omitempty"`    // Who acted
+	APIKeyID       *string                `json:"api_key_id,omitempty"` // Or which API key
+	Action         string                 `json:"action"`
+	EntityType     *string                `json:"entity_type,omitempty"`
+	EntityID       *string                `json:"entity_id,omitempty"`
+	Description    string                 `json:"description"`
+	Changes        map[string]interface{} `json:"changes,omitempty"` // JSONB
+	IPAddress      *string                `json:"ip_address,omitempty"`
+	UserAgent      *string                `json:"user_agent,omitempty"`
+	OccurredAt     time.Time              `json:"occurred_at"`
+}
+
+// ActivityLogRepository handles database operations for the activity log.
+type ActivityLogRepository struct {
+	db *database.Postgres
+}
+
+// NewActivityLogRepository creates a new ActivityLogRepository.
+func NewActivityLogRepository(db *database.Postgres) *ActivityLogRepository {
+	return &ActivityLogRepository{db: db}
+}
+
+// Record appends an entry to the activity log.
+func (r *ActivityLogRepository) Record(ctx context.Context, e *ActivityEntry) error {
+	query := `
+		INSERT INTO activity_log (
+			organization_id, user_id, api_key_id, action, entity_type, entity_id, description, changes, ip_address, user_agent
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9::inet, $10
+		) RETURNING id, occurred_at`
+
+	err := r.db.Pool.QueryRow(ctx, query,
+		e.OrganizationID, e.UserID, e.APIKeyID, e.Action, e.EntityType, e.EntityID, e.Description, e.Changes, e.IPAddress, e.UserAgent,
+	).Scan(&e.ID, &e.OccurredAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+
+	return nil
+}
+
+// ListEntityActivity returns the activity recorded for an entity, newest first.
+func (r *ActivityLogRepository) ListEntityActivity(ctx context.Context, entityType, entityID string, limit int) ([]ActivityEntry, error) {
+	query := `
+		SELECT
+			id, organization_id, user_id, api_key_id, action, entity_type, entity_id, description, changes,
+			host(ip_address), user_agent, occurred_at
+		FROM activity_log
+		WHERE entity_type = $1 AND entity_id = $2
+		ORDER BY occurred_at DESC, id
+		LIMIT $3`
+
+	rows, err := r.db.Pool.Query(ctx, query, entityType, entityID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list activity: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []ActivityEntry{}
+	for rows.Next() {
+		var e ActivityEntry
+		if err := rows.Scan(
+			&e.ID, &e.OrganizationID, &e.UserID, &e.APIKeyID, &e.Action, &e.EntityType, &e.EntityID, &e.Description, &e.Changes,
+			&e.IPAddress, &e.UserAgent, &e.OccurredAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan activity: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list activity: %w", err)
+	}
+
+	return entries, nil
+}
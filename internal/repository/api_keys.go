@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/off-by-2/sal/internal/database"
+)
+
+// ErrAPIKeyNotFound is returned when an API key is unknown, expired, revoked or belongs to another organization.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// apiKeyTouchInterval is how often last_used_at is written for a key in constant use.
+const apiKeyTouchInterval = time.Minute
+
+// APIKey represents a row in the api_keys table. The key itself is only known when it is created.
+type APIKey struct {
+	ID             string     `json:"id"`
+	OrganizationID string     `json:"organization_id"`
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix"` // Public start of the key, to recognise it
+	Capabilities   []string   `json:"capabilities"`
+	CreatedBy      *string    `json:"created_by,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// apiKeyColumns is the column list read by (*APIKey).fields.
+const apiKeyColumns = `
+	id, organization_id, name, prefix, capabilities, created_by, expires_at, last_used_at, revoked_at, created_at`
+
+// fields returns the scan destinations for apiKeyColumns.
+func (k *APIKey) fields() []interface{} {
+	return []interface{}{
+		&k.ID, &k.OrganizationID, &k.Name, &k.Prefix, &k.Capabilities, &k.CreatedBy,
+		&k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt,
+	}
+}
+
+// APIKeyRepository handles database operations for API keys.
+type APIKeyRepository struct {
+	db *database.Postgres
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository.
+func NewAPIKeyRepository(db *database.Postgres) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// CreateAPIKey inserts a key with the SHA-256 hash of its secret.
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, k *APIKey, keyHash string) error {
+	if k.Capabilities == nil {
+		k.Capabilities = []string{}
+	}
+
+	err := r.db.Pool.QueryRow(ctx, `
+		INSERT INTO api_keys (organization_id, name, prefix, key_hash, capabilities, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`,
+		k.OrganizationID, k.Name, k.Prefix, keyHash, k.Capabilities, k.CreatedBy, k.ExpiresAt,
+	).Scan(&k.ID, &k.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// ListOrgAPIKeys returns the keys of an organization, revoked and expired ones included, newest first.
+func (r *APIKeyRepository) ListOrgAPIKeys(ctx context.Context, orgID string) ([]APIKey, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE organization_id = $1
+		ORDER BY created_at DESC, id`,
+		orgID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var k APIKey
+		if err := rows.Scan(k.fields()...); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes a key of an organization. Revoking it again is a no-op.
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, orgID, keyID string) (*APIKey, error) {
+	var k APIKey
+	err := r.db.Pool.QueryRow(ctx, `
+		UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now())
+		WHERE id = $1 AND organization_id = $2
+		RETURNING `+apiKeyColumns,
+		keyID, orgID,
+	).Scan(k.fields()...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return &k, nil
+}
+
+// UseAPIKey returns the active key matching prefix and hash, and records that it was used.
+// last_used_at is written at most once per apiKeyTouchInterval, so busy keys don't rewrite their row on every request.
+func (r *APIKeyRepository) UseAPIKey(ctx context.Context, prefix, keyHash string) (*APIKey, error) {
+	var k APIKey
+	err := r.db.Pool.QueryRow(ctx, `
+		WITH active AS (
+			SELECT id FROM api_keys
+			WHERE prefix = $1 AND key_hash = $2
+			  AND revoked_at IS NULL
+			  AND (expires_at IS NULL OR expires_at > now())
+		), touched AS (
+			UPDATE api_keys SET last_used_at = now()
+			WHERE id IN (SELECT id FROM active)
+			  AND (last_used_at IS NULL OR last_used_at < $3)
+		)
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE id IN (SELECT id FROM active)`,
+		prefix, keyHash, time.Now().Add(-apiKeyTouchInterval),
+	).Scan(k.fields()...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &k, nil
+}
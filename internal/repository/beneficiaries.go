@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/off-by-2/sal/internal/database"
+)
+
+// ErrMedicalRecordNumberTaken is returned when another beneficiary of the organization has the medical record number.
+var ErrMedicalRecordNumberTaken = errors.New("medical record number taken")
+
+// Beneficiary represents a row in the beneficiaries table (patients or residents). Contains PHI.
+type Beneficiary struct {
+	ID                  string    `json:"id"`
+	OrganizationID      string    `json:"organization_id"`
+	FirstName           string    `json:"first_name"`
+	LastName            string    `json:"last_name"`
+	DateOfBirth         time.Time `json:"date_of_birth"`
+	MedicalRecordNumber string    `json:"medical_record_number"`
+	Phone               *string   `json:"phone,omitempty"`
+	Email               *string   `json:"email,omitempty"`
+	IsActive            bool      `json:"is_active"`
+	CreatedBy           string    `json:"created_by"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// BeneficiaryRepository handles database operations for beneficiaries.
+type BeneficiaryRepository struct {
+	db *database.Postgres
+}
+
+// NewBeneficiaryRepository creates a new BeneficiaryRepository.
+func NewBeneficiaryRepository(db *database.Postgres) *BeneficiaryRepository {
+	return &BeneficiaryRepository{db: db}
+}
+
+// CreateBeneficiary inserts a beneficiary. It fails with ErrMedicalRecordNumberTaken,
+// or a *QuotaError when the organization has reached max_beneficiaries.
+func (r *BeneficiaryRepository) CreateBeneficiary(ctx context.Context, b *Beneficiary) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := reserveQuota(ctx, tx, b.OrganizationID, QuotaBeneficiaries); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO beneficiaries (
+			organization_id, first_name, last_name, date_of_birth, medical_record_number, phone, email, created_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		) RETURNING id, is_active, created_at, updated_at`,
+		b.OrganizationID, b.FirstName, b.LastName, b.DateOfBirth, b.MedicalRecordNumber, b.Phone, b.Email, b.CreatedBy,
+	).Scan(&b.ID, &b.IsActive, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrMedicalRecordNumberTaken
+		}
+		return fmt.Errorf("failed to create beneficiary: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit beneficiary: %w", err)
+	}
+
+	return nil
+}
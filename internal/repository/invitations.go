@@ -0,0 +1,447 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/off-by-2/sal/internal/database"
+)
+
+// Invitation statuses (invitation_status_type).
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationExpired  = "expired"
+	InvitationRevoked  = "revoked"
+)
+
+var (
+	// ErrInvitationNotFound is returned when an invitation does not exist in the organization.
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvitationClosed is returned when an accepted or revoked invitation is resent or revoked.
+	ErrInvitationClosed = errors.New("invitation already accepted or revoked")
+	// ErrInvitationInvalid is returned when an invitation token is unknown, expired or no longer pending.
+	ErrInvitationInvalid = errors.New("invitation invalid")
+	// ErrInvitationPending is returned when the email already has a pending invitation to the organization.
+	ErrInvitationPending = errors.New("invitation already pending")
+	// ErrAlreadyMember is returned when the invited email belongs to active staff of the organization.
+	ErrAlreadyMember = errors.New("already a member")
+	// ErrGroupNotFound is returned when a group does not exist in the organization or is archived.
+	ErrGroupNotFound = errors.New("group not found")
+	// ErrAccountRequired is returned when accepting an invitation for an email without account and no password was given.
+	ErrAccountRequired = errors.New("account required")
+)
+
+// Invitation represents a row in the staff_invitations table. The token is only known when it is sent.
+type Invitation struct {
+	ID               string      `json:"id"`
+	OrganizationID   string      `json:"organization_id"`
+	OrganizationName string      `json:"organization_name"`
+	GroupID          *string     `json:"group_id,omitempty"`
+	Email            string      `json:"email"`
+	FirstName        *string     `json:"first_name,omitempty"`
+	LastName         *string     `json:"last_name,omitempty"`
+	Role             string      `json:"role"`
+	Permissions      Permissions `json:"permissions"`
+	Status           string      `json:"status"` // Pending invitations past expires_at are reported as expired
+	InvitedBy        string      `json:"invited_by"`
+	ExpiresAt        time.Time   `json:"expires_at"`
+	SentAt           time.Time   `json:"sent_at"`
+	AcceptedAt       *time.Time  `json:"accepted_at,omitempty"`
+	AcceptedByUserID *string     `json:"accepted_by_user_id,omitempty"`
+	RevokedAt        *time.Time  `json:"revoked_at,omitempty"`
+	CreatedAt        time.Time   `json:"created_at"`
+}
+
+// InvitedUser is the account to create when an invitation is accepted by someone without one.
+type InvitedUser struct {
+	FirstName    string
+	LastName     string
+	PasswordHash string
+}
+
+// invitationColumns is the column list read by (*Invitation).fields, for staff_invitations aliased as i
+// joined with organizations aliased as o.
+const invitationColumns = `
+	i.id, i.organization_id, o.name, i.group_id, i.email, i.first_name, i.last_name, i.role, i.permissions,
+	CASE WHEN i.status = 'pending' AND i.expires_at <= now() THEN 'expired' ELSE i.status::text END,
+	i.invited_by, i.expires_at, i.sent_at, i.accepted_at, i.accepted_by_user_id, i.revoked_at, i.created_at`
+
+// fields returns the scan destinations for invitationColumns.
+func (i *Invitation) fields() []interface{} {
+	return []interface{}{
+		&i.ID, &i.OrganizationID, &i.OrganizationName, &i.GroupID, &i.Email, &i.FirstName, &i.LastName, &i.Role, &i.Permissions,
+		&i.Status,
+		&i.InvitedBy, &i.ExpiresAt, &i.SentAt, &i.AcceptedAt, &i.AcceptedByUserID, &i.RevokedAt, &i.CreatedAt,
+	}
+}
+
+// InvitationRepository handles database operations for staff invitations.
+type InvitationRepository struct {
+	db *database.Postgres
+}
+
+// NewInvitationRepository creates a new InvitationRepository.
+func NewInvitationRepository(db *database.Postgres) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+// CreateInvitation inserts a pending invitation with the SHA-256 hash of its token.
+// It fails with ErrAlreadyMember, ErrInvitationPending, ErrGroupNotFound or a *QuotaError when the organization
+// has no staff seat left.
+func (r *InvitationRepository) CreateInvitation(ctx context.Context, inv *Invitation, tokenHash string) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Serialize invitations of the same email to the organization
+	_, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, inv.OrganizationID+"/"+inv.Email)
+	if err != nil {
+		return fmt.Errorf("failed to lock invitation: %w", err)
+	}
+
+	var member, pending bool
+	err = tx.QueryRow(ctx, `
+		SELECT
+			EXISTS (
+				SELECT 1 FROM staff s JOIN users u ON u.id = s.user_id
+				WHERE s.organization_id = $1 AND lower(u.email) = lower($2)
+				  AND s.is_active = true AND s.deleted_at IS NULL AND u.deleted_at IS NULL
+			),
+			EXISTS (
+				SELECT 1 FROM staff_invitations
+				WHERE organization_id = $1 AND lower(email) = lower($2) AND status = 'pending' AND expires_at > now()
+			)`,
+		inv.OrganizationID, inv.Email,
+	).Scan(&member, &pending)
+	if err != nil {
+		return fmt.Errorf("failed to check invitation: %w", err)
+	}
+	if member {
+		return ErrAlreadyMember
+	}
+	if pending {
+		return ErrInvitationPending
+	}
+
+	// Not into a full organization; the seat itself is only taken on acceptance
+	if err := reserveQuota(ctx, tx, inv.OrganizationID, QuotaStaff); err != nil {
+		return err
+	}
+
+	if inv.GroupID != nil {
+		if err := checkGroup(ctx, tx, inv.OrganizationID, *inv.GroupID); err != nil {
+			return err
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO staff_invitations (
+			organization_id, group_id, email, first_name, last_name, token, role, permissions, invited_by, expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		) RETURNING id, status, sent_at, created_at, (SELECT name FROM organizations WHERE id = $1)`,
+		inv.OrganizationID, inv.GroupID, inv.Email, inv.FirstName, inv.LastName, tokenHash, inv.Role, inv.Permissions,
+		inv.InvitedBy, inv.ExpiresAt,
+	).Scan(&inv.ID, &inv.Status, &inv.SentAt, &inv.CreatedAt, &inv.OrganizationName)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit invitation: %w", err)
+	}
+
+	return nil
+}
+
+// ListOrgInvitations returns the invitations of an organization, newest first.
+// status filters by (reported) status; empty lists all.
+func (r *InvitationRepository) ListOrgInvitations(ctx context.Context, orgID, status string) ([]Invitation, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT `+invitationColumns+`
+		FROM staff_invitations i
+		JOIN organizations o ON o.id = i.organization_id
+		WHERE i.organization_id = $1
+		  AND ($2::text = '' OR $2 = CASE WHEN i.status = 'pending' AND i.expires_at <= now() THEN 'expired' ELSE i.status::text END)
+		ORDER BY i.created_at DESC, i.id`,
+		orgID, status,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	invitations := []Invitation{}
+	for rows.Next() {
+		var inv Invitation
+		if err := rows.Scan(inv.fields()...); err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		invitations = append(invitations, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+
+	return invitations, nil
+}
+
+// GetInvitationByToken retrieves the pending, unexpired invitation of a token hash.
+func (r *InvitationRepository) GetInvitationByToken(ctx context.Context, tokenHash string) (*Invitation, error) {
+	var inv Invitation
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT `+invitationColumns+`
+		FROM staff_invitations i
+		JOIN organizations o ON o.id = i.organization_id
+		WHERE i.token = $1 AND i.status = 'pending' AND i.expires_at > now() AND o.deleted_at IS NULL`,
+		tokenHash,
+	).Scan(inv.fields()...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvitationInvalid
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return &inv, nil
+}
+
+// ResendInvitation replaces the token of a pending or expired invitation and extends it until expiresAt.
+// The previous token stops working.
+func (r *InvitationRepository) ResendInvitation(ctx context.Context, orgID, id, tokenHash string, expiresAt time.Time) (*Invitation, error) {
+	return r.updateOpenInvitation(ctx, orgID, id, `
+		UPDATE staff_invitations SET token = $3, status = 'pending', sent_at = now(), expires_at = $4
+		WHERE id = $1 AND organization_id = $2`,
+		tokenHash, expiresAt,
+	)
+}
+
+// RevokeInvitation revokes a pending or expired invitation.
+func (r *InvitationRepository) RevokeInvitation(ctx context.Context, orgID, id string) (*Invitation, error) {
+	return r.updateOpenInvitation(ctx, orgID, id, `
+		UPDATE staff_invitations SET status = 'revoked', revoked_at = now()
+		WHERE id = $1 AND organization_id = $2`,
+	)
+}
+
+// ExpireInvitations marks every pending invitation past its expiry as expired and returns how many there were.
+// Reads already report them as expired; this keeps the stored status (and idx_invite_expires) in step.
+func (r *InvitationRepository) ExpireInvitations(ctx context.Context) (int64, error) {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE staff_invitations SET status = 'expired'
+		WHERE status = 'pending' AND expires_at <= now()`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire invitations: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// updateOpenInvitation runs update (with the invitation and organization IDs as $1 and $2) on an invitation
+// that is neither accepted nor revoked, and returns it updated.
+func (r *InvitationRepository) updateOpenInvitation(ctx context.Context, orgID, id, update string, args ...interface{}) (*Invitation, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var status string
+	err = tx.QueryRow(ctx, `
+		SELECT status FROM staff_invitations
+		WHERE id = $1 AND organization_id = $2
+		FOR UPDATE`,
+		id, orgID,
+	).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if status != InvitationPending && status != InvitationExpired {
+		return nil, ErrInvitationClosed
+	}
+
+	if _, err := tx.Exec(ctx, update, append([]interface{}{id, orgID}, args...)...); err != nil {
+		return nil, fmt.Errorf("failed to update invitation: %w", err)
+	}
+
+	var inv Invitation
+	err = tx.QueryRow(ctx, `
+		SELECT `+invitationColumns+`
+		FROM staff_invitations i
+		JOIN organizations o ON o.id = i.organization_id
+		WHERE i.id = $1`,
+		id,
+	).Scan(inv.fields()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit invitation: %w", err)
+	}
+
+	return &inv, nil
+}
+
+// AcceptInvitation accepts the invitation of a token hash in one transaction: it attaches the account of the
+// invited email, or creates it from newUser, then inserts (or reactivates) the staff row and the group assignment.
+// Accounts created here have a verified email, since the token was delivered to it.
+// It fails with ErrInvitationInvalid, ErrAccountRequired (no account and newUser is nil), ErrAlreadyMember
+// or a *QuotaError when the organization has no staff seat left.
+func (r *InvitationRepository) AcceptInvitation(ctx context.Context, tokenHash string, newUser *InvitedUser) (*Staff, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// 1. The invitation, locked so it is accepted once
+	var inv Invitation
+	err = tx.QueryRow(ctx, `
+		SELECT `+invitationColumns+`
+		FROM staff_invitations i
+		JOIN organizations o ON o.id = i.organization_id
+		WHERE i.token = $1 AND i.status = 'pending' AND i.expires_at > now() AND o.deleted_at IS NULL
+		FOR UPDATE OF i`,
+		tokenHash,
+	).Scan(inv.fields()...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvitationInvalid
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	// 2. The account
+	var userID string
+	err = tx.QueryRow(ctx, `
+		SELECT id FROM users WHERE lower(email) = lower($1) AND deleted_at IS NULL`,
+		inv.Email,
+	).Scan(&userID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		if newUser == nil {
+			return nil, ErrAccountRequired
+		}
+		err = tx.QueryRow(ctx, `
+			INSERT INTO users (email, password_hash, first_name, last_name, is_active, auth_provider, email_verified)
+			VALUES ($1, $2, $3, $4, true, 'email', true) RETURNING id`,
+			inv.Email, newUser.PasswordHash, newUser.FirstName, newUser.LastName,
+		).Scan(&userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// 3. A seat, unless they already hold one
+	var member bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM staff
+			WHERE organization_id = $1 AND user_id = $2 AND is_active = true AND deleted_at IS NULL
+		)`,
+		inv.OrganizationID, userID,
+	).Scan(&member)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check membership: %w", err)
+	}
+	if member {
+		return nil, ErrAlreadyMember
+	}
+	if err := reserveQuota(ctx, tx, inv.OrganizationID, QuotaStaff); err != nil {
+		return nil, err
+	}
+
+	// 4. The membership; a deactivated one is reactivated with the invited role
+	var s Staff
+	err = tx.QueryRow(ctx, `
+		INSERT INTO staff (organization_id, user_id, role, permissions, invited_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, organization_id) DO UPDATE SET
+			role = EXCLUDED.role, permissions = EXCLUDED.permissions, invited_by = EXCLUDED.invited_by,
+			is_active = true, deactivated_at = NULL, deactivation_reason = NULL, deleted_at = NULL, joined_at = now()
+		WHERE staff.is_active = false OR staff.deleted_at IS NOT NULL
+		RETURNING id, organization_id, user_id, role, permissions, is_active, created_at, updated_at`,
+		inv.OrganizationID, userID, inv.Role, inv.Permissions, inv.InvitedBy,
+	).Scan(&s.ID, &s.OrganizationID, &s.UserID, &s.Role, &s.Permissions, &s.IsActive, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAlreadyMember
+		}
+		return nil, fmt.Errorf("failed to create staff: %w", err)
+	}
+
+	// 5. The initial group, unless it was archived since
+	if inv.GroupID != nil {
+		err := checkGroup(ctx, tx, inv.OrganizationID, *inv.GroupID)
+		switch {
+		case errors.Is(err, ErrGroupNotFound):
+		case err != nil:
+			return nil, err
+		default:
+			_, err = tx.Exec(ctx, `
+				INSERT INTO staff_group_assignments (staff_id, group_id, assigned_by)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (staff_id, group_id) DO UPDATE SET
+					is_active = true, removed_at = NULL, removed_by = NULL,
+					assigned_by = EXCLUDED.assigned_by, assigned_at = now()`,
+				s.ID, *inv.GroupID, inv.InvitedBy,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to assign group: %w", err)
+			}
+		}
+	}
+
+	// 6. Spend the invitation
+	_, err = tx.Exec(ctx, `
+		UPDATE staff_invitations SET status = 'accepted', accepted_at = now(), accepted_by_user_id = $2
+		WHERE id = $1`,
+		inv.ID, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit invitation: %w", err)
+	}
+
+	return &s, nil
+}
+
+// checkGroup returns ErrGroupNotFound unless groupID is an active group of the organization.
+func checkGroup(ctx context.Context, tx pgx.Tx, orgID, groupID string) error {
+	var exists bool
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM groups
+			WHERE id = $1 AND organization_id = $2 AND is_active = true AND archived_at IS NULL AND deleted_at IS NULL
+		)`,
+		groupID, orgID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check group: %w", err)
+	}
+	if !exists {
+		return ErrGroupNotFound
+	}
+	return nil
+}
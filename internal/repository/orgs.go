@@ -3,20 +3,77 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+
 	"github.com/off-by-2/sal/internal/database"
 )
 
+// ErrOrgNotFound is returned when an organization does not exist or was deleted.
+var ErrOrgNotFound = errors.New("organization not found")
+
 // Organization represents a row in the organizations table.
 type Organization struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Slug      string    `json:"slug"`
-	OwnerID   string    `json:"owner_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Slug      string      `json:"slug"`
+	OwnerID   string      `json:"owner_id"`
+	Settings  OrgSettings `json:"settings"` // JSONB
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+
+	// Suspension: IsActive is false while suspended, and SuspensionReadOnly keeps read access
+	IsActive           bool       `json:"is_active"`
+	SuspendedAt        *time.Time `json:"suspended_at,omitempty"`
+	SuspensionReason   *string    `json:"suspension_reason,omitempty"`
+	SuspensionReadOnly bool       `json:"suspension_read_only"`
+}
+
+// OrgSettings is the typed form of the organizations.settings JSONB document.
+// Missing keys decode to the defaults of DefaultOrgSettings.
+type OrgSettings struct {
+	Features             map[string]bool `json:"features"`    // offline_sync, ai_transcription, advanced_analytics
+	Timezone             string          `json:"timezone"`    // IANA name, e.g. "Europe/Paris"
+	DateFormat           string          `json:"date_format"` // MM/DD/YYYY, DD/MM/YYYY or YYYY-MM-DD
+	RequireTwoFactor     bool            `json:"require_two_factor"`
+	RequireVerifiedEmail bool            `json:"require_verified_email"`
+	AudioRetentionDays   int             `json:"audio_retention_days"` // 0 keeps audio forever
+}
+
+// DefaultOrgSettings returns the settings the database assigns to new organizations.
+func DefaultOrgSettings() OrgSettings {
+	return OrgSettings{
+		Features:   map[string]bool{"offline_sync": true, "ai_transcription": true, "advanced_analytics": false},
+		Timezone:   "UTC",
+		DateFormat: "MM/DD/YYYY",
+	}
+}
+
+// UnmarshalJSON decodes a settings document over the defaults.
+func (s *OrgSettings) UnmarshalJSON(data []byte) error {
+	type plain OrgSettings
+	settings := plain(DefaultOrgSettings())
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return err
+	}
+	*s = OrgSettings(settings)
+	return nil
+}
+
+// orgColumns lists the columns scanned by Organization.fields.
+const orgColumns = `id, name, COALESCE(slug, ''), owner_user_id, settings, created_at, updated_at,
+	is_active, suspended_at, suspension_reason, suspension_read_only`
+
+// fields returns the scan destinations matching orgColumns.
+func (o *Organization) fields() []interface{} {
+	return []interface{}{
+		&o.ID, &o.Name, &o.Slug, &o.OwnerID, &o.Settings, &o.CreatedAt, &o.UpdatedAt,
+		&o.IsActive, &o.SuspendedAt, &o.SuspensionReason, &o.SuspensionReadOnly,
+	}
 }
 
 // OrganizationRepository handles database operations for organizations.
@@ -36,12 +93,12 @@ func (r *OrganizationRepository) CreateOrg(ctx context.Context, o *Organization)
 			name, owner_user_id
 		) VALUES (
 			$1, $2
-		) RETURNING id, slug, created_at, updated_at`
+		) RETURNING id, slug, settings, created_at, updated_at, is_active`
 
 	// Slug is generated by DB trigger, so we scan it back
 	err := r.db.Pool.QueryRow(ctx, query,
 		o.Name, o.OwnerID,
-	).Scan(&o.ID, &o.Slug, &o.CreatedAt, &o.UpdatedAt)
+	).Scan(&o.ID, &o.Slug, &o.Settings, &o.CreatedAt, &o.UpdatedAt, &o.IsActive)
 
 	if err != nil {
 		return fmt.Errorf("failed to create organization: %w", err)
@@ -49,3 +106,146 @@ func (r *OrganizationRepository) CreateOrg(ctx context.Context, o *Organization)
 
 	return nil
 }
+
+// GetOrg retrieves an organization by ID.
+func (r *OrganizationRepository) GetOrg(ctx context.Context, id string) (*Organization, error) {
+	var o Organization
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT `+orgColumns+`
+		FROM organizations WHERE id = $1 AND deleted_at IS NULL`,
+		id,
+	).Scan(o.fields()...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrgNotFound
+		}
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return &o, nil
+}
+
+// UpdateOrg applies update to an organization and saves its name and settings, holding a row lock
+// in between so that concurrent partial updates are not lost. Renaming regenerates the slug.
+func (r *OrganizationRepository) UpdateOrg(ctx context.Context, id string, update func(o *Organization)) (*Organization, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// 1. Lock and read
+	var o Organization
+	err = tx.QueryRow(ctx, `
+		SELECT `+orgColumns+`
+		FROM organizations WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE`,
+		id,
+	).Scan(o.fields()...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrgNotFound
+		}
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	// 2. Change and write back. A NULL slug makes generate_unique_org_slug derive it from the new name.
+	update(&o)
+	err = tx.QueryRow(ctx, `
+		UPDATE organizations
+		SET name = $2, settings = $3, slug = CASE WHEN name = $2 THEN slug ELSE NULL END
+		WHERE id = $1
+		RETURNING `+orgColumns,
+		id, o.Name, o.Settings,
+	).Scan(o.fields()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update organization: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit organization: %w", err)
+	}
+
+	return &o, nil
+}
+
+// SuspendOrg suspends an organization: its staff and API keys are refused, or only allowed to read when readOnly.
+// Suspending a suspended organization updates the reason and mode but keeps suspended_at.
+func (r *OrganizationRepository) SuspendOrg(ctx context.Context, id, reason string, readOnly bool) (*Organization, error) {
+	return r.setSuspension(ctx, `
+		UPDATE organizations
+		SET is_active = false, suspended_at = COALESCE(suspended_at, now()), suspension_reason = $2, suspension_read_only = $3
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+orgColumns,
+		id, reason, readOnly,
+	)
+}
+
+// ReinstateOrg lifts the suspension of an organization.
+func (r *OrganizationRepository) ReinstateOrg(ctx context.Context, id string) (*Organization, error) {
+	return r.setSuspension(ctx, `
+		UPDATE organizations
+		SET is_active = true, suspended_at = NULL, suspension_reason = NULL, suspension_read_only = false
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+orgColumns,
+		id,
+	)
+}
+
+// setSuspension runs a suspension update returning orgColumns.
+func (r *OrganizationRepository) setSuspension(ctx context.Context, query string, args ...interface{}) (*Organization, error) {
+	var o Organization
+	err := r.db.Pool.QueryRow(ctx, query, args...).Scan(o.fields()...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrgNotFound
+		}
+		return nil, fmt.Errorf("failed to update organization suspension: %w", err)
+	}
+	return &o, nil
+}
+
+// OrgSuspension reports whether an organization is suspended and, if so, whether it keeps read-only access.
+// It satisfies auth.SuspensionChecker. Unknown organizations are not suspended; membership checks refuse them.
+func (r *OrganizationRepository) OrgSuspension(ctx context.Context, id string) (suspended, readOnly bool, err error) {
+	err = r.db.Pool.QueryRow(ctx, `
+		SELECT NOT is_active, NOT is_active AND suspension_read_only
+		FROM organizations WHERE id = $1`,
+		id,
+	).Scan(&suspended, &readOnly)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, false, fmt.Errorf("failed to check organization suspension: %w", err)
+	}
+	return suspended, readOnly, nil
+}
+
+// RequiresTwoFactor reports whether an organization requires its staff to use two-factor authentication.
+func (r *OrganizationRepository) RequiresTwoFactor(ctx context.Context, id string) (bool, error) {
+	var required bool
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT COALESCE((settings->>'require_two_factor')::boolean, false)
+		FROM organizations WHERE id = $1`,
+		id,
+	).Scan(&required)
+	if err != nil {
+		return false, fmt.Errorf("failed to get organization settings: %w", err)
+	}
+	return required, nil
+}
+
+// BlocksUnverifiedUser reports whether a user may not create notes in an organization
+// because their email is unverified and the organization sets settings.require_verified_email.
+func (r *OrganizationRepository) BlocksUnverifiedUser(ctx context.Context, orgID, userID string) (bool, error) {
+	var blocked bool
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT NOT u.email_verified AND COALESCE((o.settings->>'require_verified_email')::boolean, false)
+		FROM organizations o, users u
+		WHERE o.id = $1 AND u.id = $2`,
+		orgID, userID,
+	).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check email verification: %w", err)
+	}
+	return blocked, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrResetTokenInvalid is returned when a password reset token is unknown, expired or already used.
+var ErrResetTokenInvalid = errors.New("password reset token invalid")
+
+// CreatePasswordReset stores the hash of a password reset token for a user.
+func (r *UserRepository) CreatePasswordReset(ctx context.Context, userID, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)`,
+		userID, tokenHash, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store password reset: %w", err)
+	}
+	return nil
+}
+
+// GetUserByPasswordReset retrieves the user of a valid (unused, unexpired) password reset token.
+func (r *UserRepository) GetUserByPasswordReset(ctx context.Context, tokenHash string) (*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = (
+		SELECT user_id FROM password_reset_tokens
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+	)`
+	u, err := scanUser(r.db.Pool.QueryRow(ctx, query, tokenHash))
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrResetTokenInvalid
+	}
+	return u, err
+}
+
+// ResetPassword consumes a password reset token and sets the new password hash of its user.
+// Every outstanding reset token of the user is spent, and any login lockout is lifted.
+// Since the token was emailed, the email address is marked verified as well.
+// It returns the ID of the user whose password was reset.
+func (r *UserRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (string, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var userID string
+	err = tx.QueryRow(ctx, `
+		SELECT user_id FROM password_reset_tokens
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+		FOR UPDATE`,
+		tokenHash,
+	).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrResetTokenInvalid
+		}
+		return "", fmt.Errorf("failed to get password reset: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE users SET
+			password_hash = $2, email_verified = true, failed_login_attempts = 0, locked_until = NULL
+		WHERE id = $1`,
+		userID, passwordHash,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to update password: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE password_reset_tokens SET used_at = now()
+		WHERE user_id = $1 AND used_at IS NULL`,
+		userID,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to spend password resets: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("failed to commit password reset: %w", err)
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+// Capability strings accepted by Permissions.Allows.
+// They mirror the "<section>.<action>" paths of the staff.permissions JSONB document.
+const (
+	CapNotesRead           = "notes.read"
+	CapNotesCreate         = "notes.create"
+	CapNotesDelete         = "notes.delete"
+	CapNotesUpdateAny      = "notes.update_any"
+	CapNotesUpdateOwn      = "notes.update_own"
+	CapStaffInvite         = "staff.invite"
+	CapStaffManage         = "staff.manage"
+	CapDashboardView       = "dashboard.view"
+	CapDashboardExport     = "dashboard.export"
+	CapTemplatesCreate     = "templates.create"
+	CapTemplatesManage     = "templates.manage"
+	CapBeneficiariesRead   = "beneficiaries.read"
+	CapBeneficiariesCreate = "beneficiaries.create"
+	CapBeneficiariesDelete = "beneficiaries.delete"
+	CapBeneficiariesUpdate = "beneficiaries.update"
+)
+
+// Permissions is the typed form of the staff.permissions JSONB document.
+// Missing keys decode as false, so an empty document grants nothing.
+type Permissions struct {
+	Notes         NotePermissions        `json:"notes"`
+	Staff         StaffPermissions       `json:"staff"`
+	Dashboard     DashboardPermissions   `json:"dashboard"`
+	Templates     TemplatePermissions    `json:"templates"`
+	Beneficiaries BeneficiaryPermissions `json:"beneficiaries"`
+}
+
+// NotePermissions controls access to clinical notes.
+type NotePermissions struct {
+	Read      bool `json:"read"`
+	Create    bool `json:"create"`
+	Delete    bool `json:"delete"`
+	UpdateAny bool `json:"update_any"`
+	UpdateOwn bool `json:"update_own"`
+}
+
+// StaffPermissions controls staff administration.
+type StaffPermissions struct {
+	Invite bool `json:"invite"`
+	Manage bool `json:"manage"`
+}
+
+// DashboardPermissions controls access to analytics.
+type DashboardPermissions struct {
+	View   bool `json:"view"`
+	Export bool `json:"export"`
+}
+
+// TemplatePermissions controls form template management.
+type TemplatePermissions struct {
+	Create bool `json:"create"`
+	Manage bool `json:"manage"`
+}
+
+// BeneficiaryPermissions controls access to patient records.
+type BeneficiaryPermissions struct {
+	Read   bool `json:"read"`
+	Create bool `json:"create"`
+	Delete bool `json:"delete"`
+	Update bool `json:"update"`
+}
+
+// DefaultPermissions returns the permissions the database assigns to new staff.
+func DefaultPermissions() Permissions {
+	return Permissions{
+		Notes:         NotePermissions{Read: true, Create: true, UpdateOwn: true},
+		Beneficiaries: BeneficiaryPermissions{Read: true, Create: true},
+	}
+}
+
+// Permission presets offered when inviting staff.
+const (
+	PresetStaff      = "staff"      // DefaultPermissions
+	PresetSupervisor = "supervisor" // Also edits anyone's notes and patient records, sees the dashboard
+	PresetManager    = "manager"    // Also invites and manages staff, exports, manages templates
+)
+
+// PermissionPreset returns the permissions of a named preset.
+func PermissionPreset(name string) (Permissions, bool) {
+	p := DefaultPermissions()
+	switch name {
+	case PresetStaff:
+	case PresetManager:
+		p.Staff = StaffPermissions{Invite: true, Manage: true}
+		p.Dashboard.Export = true
+		p.Templates = TemplatePermissions{Create: true, Manage: true}
+		fallthrough
+	case PresetSupervisor:
+		p.Notes.UpdateAny = true
+		p.Beneficiaries.Update = true
+		p.Dashboard.View = true
+	default:
+		return Permissions{}, false
+	}
+	return p, true
+}
+
+// capabilities maps every known capability to its flag in the document.
+var capabilities = map[string]func(p *Permissions) *bool{
+	CapNotesRead:           func(p *Permissions) *bool { return &p.Notes.Read },
+	CapNotesCreate:         func(p *Permissions) *bool { return &p.Notes.Create },
+	CapNotesDelete:         func(p *Permissions) *bool { return &p.Notes.Delete },
+	CapNotesUpdateAny:      func(p *Permissions) *bool { return &p.Notes.UpdateAny },
+	CapNotesUpdateOwn:      func(p *Permissions) *bool { return &p.Notes.UpdateOwn },
+	CapStaffInvite:         func(p *Permissions) *bool { return &p.Staff.Invite },
+	CapStaffManage:         func(p *Permissions) *bool { return &p.Staff.Manage },
+	CapDashboardView:       func(p *Permissions) *bool { return &p.Dashboard.View },
+	CapDashboardExport:     func(p *Permissions) *bool { return &p.Dashboard.Export },
+	CapTemplatesCreate:     func(p *Permissions) *bool { return &p.Templates.Create },
+	CapTemplatesManage:     func(p *Permissions) *bool { return &p.Templates.Manage },
+	CapBeneficiariesRead:   func(p *Permissions) *bool { return &p.Beneficiaries.Read },
+	CapBeneficiariesCreate: func(p *Permissions) *bool { return &p.Beneficiaries.Create },
+	CapBeneficiariesDelete: func(p *Permissions) *bool { return &p.Beneficiaries.Delete },
+	CapBeneficiariesUpdate: func(p *Permissions) *bool { return &p.Beneficiaries.Update },
+}
+
+// destructive lists the capabilities that destroy data. They are never used while impersonating.
+var destructive = map[string]bool{
+	CapNotesDelete:         true,
+	CapBeneficiariesDelete: true,
+}
+
+// IsDestructive reports whether capability destroys data.
+func IsDestructive(capability string) bool {
+	return destructive[capability]
+}
+
+// sensitive lists the capabilities that require a recent authentication: destroying data
+// (e.g. archiving a note into deleted_notes_archive) and overriding other people's work (admin edits).
+var sensitive = map[string]bool{
+	CapNotesDelete:         true,
+	CapNotesUpdateAny:      true,
+	CapBeneficiariesDelete: true,
+}
+
+// IsSensitive reports whether capability requires the user to have proven their credentials recently.
+func IsSensitive(capability string) bool {
+	return sensitive[capability]
+}
+
+// IsCapability reports whether c is a known capability string.
+func IsCapability(c string) bool {
+	_, ok := capabilities[c]
+	return ok
+}
+
+// Allows reports whether the document grants the capability.
+// Unknown capabilities are never granted.
+func (p Permissions) Allows(capability string) bool {
+	flag, ok := capabilities[capability]
+	if !ok {
+		return false
+	}
+	return *flag(&p)
+}
+
+// Covers reports whether p grants every capability q grants.
+func (p Permissions) Covers(q Permissions) bool {
+	for c := range capabilities {
+		if q.Allows(c) && !p.Allows(c) {
+			return false
+		}
+	}
+	return true
+}
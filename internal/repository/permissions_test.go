@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPermissions_Allows(t *testing.T) {
+	var p Permissions
+	doc := `{"notes": {"read": true, "update_any": true}, "dashboard": {"export": true}}`
+	if err := json.Unmarshal([]byte(doc), &p); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	tests := []struct {
+		capability string
+		want       bool
+	}{
+		{CapNotesRead, true},
+		{CapNotesUpdateAny, true},
+		{CapDashboardExport, true},
+		{CapNotesDelete, false},
+		{CapStaffManage, false}, // missing section
+		{"notes.unknown", false},
+	}
+
+	for _, tc := range tests {
+		if got := p.Allows(tc.capability); got != tc.want {
+			t.Errorf("Allows(%q) = %v, want %v", tc.capability, got, tc.want)
+		}
+	}
+}
+
+func TestDefaultPermissions(t *testing.T) {
+	p := DefaultPermissions()
+	if !p.Allows(CapNotesUpdateOwn) || p.Allows(CapNotesUpdateAny) {
+		t.Error("Default permissions should allow updating own notes only")
+	}
+	if !IsCapability(CapTemplatesManage) || IsCapability("templates") {
+		t.Error("IsCapability mismatch")
+	}
+}
+
+func TestPermissionPreset(t *testing.T) {
+	staff, _ := PermissionPreset(PresetStaff)
+	supervisor, _ := PermissionPreset(PresetSupervisor)
+	manager, ok := PermissionPreset(PresetManager)
+	if !ok {
+		t.Fatal("Expected the manager preset to exist")
+	}
+	if _, ok := PermissionPreset("owner"); ok {
+		t.Error("Expected unknown presets to be refused")
+	}
+
+	if staff != DefaultPermissions() {
+		t.Error("Expected the staff preset to be the default permissions")
+	}
+	if !manager.Covers(supervisor) || !supervisor.Covers(staff) || staff.Covers(supervisor) {
+		t.Error("Expected each preset to extend the previous one")
+	}
+	if !supervisor.Allows(CapNotesUpdateAny) || supervisor.Allows(CapStaffInvite) || !manager.Allows(CapStaffManage) {
+		t.Errorf("Unexpected presets: %+v %+v", supervisor, manager)
+	}
+}
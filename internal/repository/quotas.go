@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Organization quotas, the organizations.max_* limits.
+const (
+	QuotaStaff         = "staff"         // Active staff, max_staff
+	QuotaBeneficiaries = "beneficiaries" // Beneficiaries not deleted, max_beneficiaries
+)
+
+// ErrQuotaExceeded is returned (as a *QuotaError) when a creation would exceed a quota of the organization.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaError tells which quota a creation would exceed.
+type QuotaError struct {
+	Quota string
+	Limit int
+}
+
+// Error implements error.
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s quota of %d exceeded", e.Quota, e.Limit)
+}
+
+// Unwrap makes errors.Is(err, ErrQuotaExceeded) match.
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// quotaUsage selects the limit and current count of each quota for an organization aliased o.
+var quotaUsage = map[string]string{
+	QuotaStaff: `o.max_staff, (
+		SELECT count(*) FROM staff s
+		WHERE s.organization_id = o.id AND s.is_active = true AND s.deleted_at IS NULL
+	)`,
+	QuotaBeneficiaries: `o.max_beneficiaries, (
+		SELECT count(*) FROM beneficiaries b
+		WHERE b.organization_id = o.id AND b.deleted_at IS NULL
+	)`,
+}
+
+// reserveQuota makes room for one more of quota in the organization within tx, or fails with a *QuotaError.
+// It locks the organization row until tx ends, so concurrent creations are counted one after another
+// instead of all seeing the last free slot. Call it before inserting. A NULL limit is unlimited.
+func reserveQuota(ctx context.Context, tx pgx.Tx, orgID, quota string) error {
+	var limit *int
+	var used int
+	err := tx.QueryRow(ctx, `
+		SELECT `+quotaUsage[quota]+`
+		FROM organizations o WHERE o.id = $1
+		FOR UPDATE OF o`,
+		orgID,
+	).Scan(&limit, &used)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrOrgNotFound
+		}
+		return fmt.Errorf("failed to check %s quota: %w", quota, err)
+	}
+	if limit != nil && used >= *limit {
+		return &QuotaError{Quota: quota, Limit: *limit}
+	}
+	return nil
+}
+
+// QuotaUsage is the current count of a quota against its limit.
+type QuotaUsage struct {
+	Used  int  `json:"used"`
+	Limit *int `json:"limit"` // Null when unlimited
+}
+
+// OrgUsage reports every quota of an organization.
+type OrgUsage struct {
+	Staff         QuotaUsage `json:"staff"`
+	Beneficiaries QuotaUsage `json:"beneficiaries"`
+}
+
+// GetOrgUsage returns the quota usage of an organization.
+func (r *OrganizationRepository) GetOrgUsage(ctx context.Context, orgID string) (*OrgUsage, error) {
+	var u OrgUsage
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT `+quotaUsage[QuotaStaff]+`, `+quotaUsage[QuotaBeneficiaries]+`
+		FROM organizations o WHERE o.id = $1 AND o.deleted_at IS NULL`,
+		orgID,
+	).Scan(&u.Staff.Limit, &u.Staff.Used, &u.Beneficiaries.Limit, &u.Beneficiaries.Used)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrgNotFound
+		}
+		return nil, fmt.Errorf("failed to get organization usage: %w", err)
+	}
+	return &u, nil
+}
@@ -0,0 +1,449 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/off-by-2/sal/internal/database"
+)
+
+var (
+	// ErrRefreshTokenNotFound is returned when a refresh token hash is unknown.
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	// ErrRefreshTokenExpired is returned when a refresh token is past its expiry.
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	// ErrRefreshTokenReused is returned when an already-rotated refresh token is presented.
+	// The whole session (token family) is revoked before this error is returned.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrSessionRevoked is returned when the session owning a token has been revoked.
+	ErrSessionRevoked = errors.New("session revoked")
+	// ErrSessionNotFound is returned when a session does not exist or belongs to someone else.
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// Session represents a row in the user_sessions table.
+// A session is created on login and owns a family of rotating refresh tokens.
+type Session struct {
+	ID             string     `json:"id"`
+	UserID         string     `json:"user_id"`
+	OrganizationID *string    `json:"organization_id,omitempty"`
+	DeviceID       *string    `json:"device_id,omitempty"`
+	UserAgent      *string    `json:"user_agent,omitempty"`
+	IPAddress      *string    `json:"ip_address,omitempty"` // Of the last login or refresh
+	ExpiresAt      time.Time  `json:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt     time.Time  `json:"last_used_at"` // Of the last login or refresh
+	ImpersonatorID *string    `json:"impersonator_id,omitempty"`
+	AuthTime       time.Time  `json:"auth_time"` // When the user last proved their credentials
+	AMR            []string   `json:"amr"`       // How, see auth.AMRPassword
+	CreatedAt      time.Time  `json:"created_at"`
+	Current        bool       `json:"current"` // Set by handlers for the caller's own session
+}
+
+// SessionClient describes the client presenting a refresh token.
+type SessionClient struct {
+	UserAgent string
+	IPAddress string
+}
+
+// sessionColumns is the column list read by (*Session).fields, for user_sessions aliased as s.
+const sessionColumns = `
+	s.id, s.user_id, s.organization_id, s.device_id, s.user_agent, host(s.ip_address),
+	s.expires_at, s.revoked_at, s.last_used_at, s.impersonator_id, s.auth_time, s.amr, s.created_at`
+
+// fields returns the scan destinations for sessionColumns.
+func (s *Session) fields() []interface{} {
+	return []interface{}{
+		&s.ID, &s.UserID, &s.OrganizationID, &s.DeviceID, &s.UserAgent, &s.IPAddress,
+		&s.ExpiresAt, &s.RevokedAt, &s.LastUsedAt, &s.ImpersonatorID, &s.AuthTime, &s.AMR, &s.CreatedAt,
+	}
+}
+
+// SessionRepository handles database operations for sessions and refresh tokens.
+type SessionRepository struct {
+	db *database.Postgres
+}
+
+// NewSessionRepository creates a new SessionRepository.
+func NewSessionRepository(db *database.Postgres) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// CreateSession inserts a new session together with its first refresh token hash.
+// The refresh token expires at s.ExpiresAt. The user authenticated with s.AMR now.
+func (r *SessionRepository) CreateSession(ctx context.Context, s *Session, tokenHash string) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO user_sessions (
+			user_id, organization_id, device_id, user_agent, ip_address, expires_at, amr
+		) VALUES (
+			$1, $2, $3, $4, $5::inet, $6, $7
+		) RETURNING id, last_used_at, auth_time, created_at`,
+		s.UserID, s.OrganizationID, s.DeviceID, s.UserAgent, s.IPAddress, s.ExpiresAt, amrOrEmpty(s.AMR),
+	).Scan(&s.ID, &s.LastUsedAt, &s.AuthTime, &s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)`,
+		s.ID, tokenHash, s.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit session: %w", err)
+	}
+
+	return nil
+}
+
+// CreateImpersonationSession inserts a session for s.ImpersonatorID acting as s.UserID.
+// It has no refresh token, so it ends at s.ExpiresAt at the latest.
+func (r *SessionRepository) CreateImpersonationSession(ctx context.Context, s *Session) error {
+	err := r.db.Pool.QueryRow(ctx, `
+		INSERT INTO user_sessions (
+			user_id, organization_id, impersonator_id, user_agent, ip_address, expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5::inet, $6
+		) RETURNING id, last_used_at, created_at`,
+		s.UserID, s.OrganizationID, s.ImpersonatorID, s.UserAgent, s.IPAddress, s.ExpiresAt,
+	).Scan(&s.ID, &s.LastUsedAt, &s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one within the same session.
+//
+// The presented token is marked as rotated and the new hash is stored with the given expiry.
+// The session records the client as last seen now.
+// If the presented token was already rotated, the token is being replayed (e.g. it was stolen),
+// so the whole session is revoked and ErrRefreshTokenReused is returned.
+// check, if not nil, vets the session before anything is rotated: its error is returned as is
+// and the presented token stays usable.
+func (r *SessionRepository) RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, expiresAt time.Time, client SessionClient, check func(*Session) error) (*Session, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Lock both rows so concurrent refreshes of the same token are serialized.
+	var (
+		s              Session
+		tokenID        string
+		tokenExpiresAt time.Time
+		rotatedAt      *time.Time
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT t.id, t.expires_at, t.rotated_at, `+sessionColumns+`
+		FROM refresh_tokens t
+		JOIN user_sessions s ON s.id = t.session_id
+		WHERE t.token_hash = $1
+		FOR UPDATE OF t, s`,
+		tokenHash,
+	).Scan(append([]interface{}{&tokenID, &tokenExpiresAt, &rotatedAt}, s.fields()...)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRefreshTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if s.RevokedAt != nil {
+		return nil, ErrSessionRevoked
+	}
+
+	if rotatedAt != nil {
+		_, err = tx.Exec(ctx, `
+			UPDATE user_sessions SET revoked_at = now(), revoked_reason = 'token_reuse'
+			WHERE id = $1 AND revoked_at IS NULL`,
+			s.ID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to revoke session: %w", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("failed to commit session revocation: %w", err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(tokenExpiresAt) {
+		return nil, ErrRefreshTokenExpired
+	}
+
+	if check != nil {
+		if err := check(&s); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE refresh_tokens SET rotated_at = now() WHERE id = $1`, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)`,
+		s.ID, newTokenHash, expiresAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE user_sessions SET
+			expires_at = $2,
+			last_used_at = now(),
+			user_agent = COALESCE(NULLIF($3, ''), user_agent),
+			ip_address = COALESCE(NULLIF($4, '')::inet, ip_address)
+		WHERE id = $1
+		RETURNING expires_at, last_used_at, user_agent, host(ip_address)`,
+		s.ID, expiresAt, client.UserAgent, client.IPAddress,
+	).Scan(&s.ExpiresAt, &s.LastUsedAt, &s.UserAgent, &s.IPAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extend session: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit rotation: %w", err)
+	}
+
+	return &s, nil
+}
+
+// GetSessionByRefreshToken retrieves the session owning a refresh token hash.
+// Rotated tokens still resolve to their session.
+func (r *SessionRepository) GetSessionByRefreshToken(ctx context.Context, tokenHash string) (*Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM refresh_tokens t
+		JOIN user_sessions s ON s.id = t.session_id
+		WHERE t.token_hash = $1`
+
+	var s Session
+	err := r.db.Pool.QueryRow(ctx, query, tokenHash).Scan(s.fields()...)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRefreshTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return &s, nil
+}
+
+// SetSessionOrganization re-scopes an active session to another organization,
+// so later refreshes issue tokens for that organization.
+func (r *SessionRepository) SetSessionOrganization(ctx context.Context, id, orgID string) error {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE user_sessions SET organization_id = $2
+		WHERE id = $1 AND revoked_at IS NULL`,
+		id, orgID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to switch session organization: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// SetSessionAuthentication records that the user of an active session proved their credentials again, now.
+// It returns the new authentication time.
+func (r *SessionRepository) SetSessionAuthentication(ctx context.Context, id string, amr []string) (time.Time, error) {
+	var authTime time.Time
+	err := r.db.Pool.QueryRow(ctx, `
+		UPDATE user_sessions SET auth_time = now(), amr = $2
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING auth_time`,
+		id, amrOrEmpty(amr),
+	).Scan(&authTime)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, ErrSessionRevoked
+		}
+		return time.Time{}, fmt.Errorf("failed to record authentication: %w", err)
+	}
+	return authTime, nil
+}
+
+// amrOrEmpty maps nil to an empty array, as user_sessions.amr is NOT NULL.
+func amrOrEmpty(amr []string) []string {
+	if amr == nil {
+		return []string{}
+	}
+	return amr
+}
+
+// IsSessionRevoked reports whether a session has been revoked.
+// Unknown sessions are reported as revoked.
+func (r *SessionRepository) IsSessionRevoked(ctx context.Context, id string) (bool, error) {
+	var revoked bool
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT revoked_at IS NOT NULL FROM user_sessions WHERE id = $1`,
+		id,
+	).Scan(&revoked)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// RevokeSession revokes a single session. Revoking an already revoked session is a no-op.
+func (r *SessionRepository) RevokeSession(ctx context.Context, id, reason string) error {
+	_, err := r.db.Pool.Exec(ctx, `
+		UPDATE user_sessions SET revoked_at = now(), revoked_reason = $2
+		WHERE id = $1 AND revoked_at IS NULL`,
+		id, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// ListUserSessions returns the active (neither revoked nor expired) sessions of a user, most recently used first.
+func (r *SessionRepository) ListUserSessions(ctx context.Context, userID string) ([]Session, error) {
+	return r.listSessions(ctx, `
+		SELECT `+sessionColumns+`
+		FROM user_sessions s
+		WHERE s.user_id = $1 AND s.revoked_at IS NULL AND s.expires_at > now()
+		ORDER BY s.last_used_at DESC`,
+		userID,
+	)
+}
+
+// ListUserOrgSessions returns the active sessions of a user scoped to one organization, most recently used first.
+func (r *SessionRepository) ListUserOrgSessions(ctx context.Context, userID, orgID string) ([]Session, error) {
+	return r.listSessions(ctx, `
+		SELECT `+sessionColumns+`
+		FROM user_sessions s
+		WHERE s.user_id = $1 AND s.organization_id = $2 AND s.revoked_at IS NULL AND s.expires_at > now()
+		ORDER BY s.last_used_at DESC`,
+		userID, orgID,
+	)
+}
+
+// listSessions runs a query selecting sessionColumns.
+func (r *SessionRepository) listSessions(ctx context.Context, query string, args ...interface{}) ([]Session, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(s.fields()...); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeUserSession revokes one active session of a user.
+// Returns ErrSessionNotFound if the user holds no such active session.
+func (r *SessionRepository) RevokeUserSession(ctx context.Context, userID, id, reason string) error {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE user_sessions SET revoked_at = now(), revoked_reason = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		id, userID, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeUserOrgSession revokes one active session of a user scoped to one organization.
+// Returns ErrSessionNotFound if the user holds no such active session there.
+func (r *SessionRepository) RevokeUserOrgSession(ctx context.Context, userID, orgID, id, reason string) error {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE user_sessions SET revoked_at = now(), revoked_reason = $4
+		WHERE id = $1 AND user_id = $2 AND organization_id = $3 AND revoked_at IS NULL`,
+		id, userID, orgID, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes every active session of a user and returns how many were revoked.
+func (r *SessionRepository) RevokeUserSessions(ctx context.Context, userID, reason string) (int64, error) {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE user_sessions SET revoked_at = now(), revoked_reason = $2
+		WHERE user_id = $1 AND revoked_at IS NULL`,
+		userID, reason,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// RevokeOtherUserSessions revokes every active session of a user except keepID.
+func (r *SessionRepository) RevokeOtherUserSessions(ctx context.Context, userID, keepID, reason string) (int64, error) {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE user_sessions SET revoked_at = now(), revoked_reason = $3
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`,
+		userID, keepID, reason,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// RevokeUserOrgSessions revokes every active session of a user scoped to one organization.
+func (r *SessionRepository) RevokeUserOrgSessions(ctx context.Context, userID, orgID, reason string) (int64, error) {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE user_sessions SET revoked_at = now(), revoked_reason = $3
+		WHERE user_id = $1 AND organization_id = $2 AND revoked_at IS NULL`,
+		userID, orgID, reason,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
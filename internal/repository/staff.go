@@ -2,21 +2,60 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+
 	"github.com/off-by-2/sal/internal/database"
 )
 
+var (
+	// ErrStaffNotFound is returned when a staff membership cannot be found.
+	ErrStaffNotFound = errors.New("staff not found")
+	// ErrStaffOwner is returned when deactivating the membership of the organization's owner.
+	ErrStaffOwner = errors.New("organization owner")
+	// ErrLastAdmin is returned when deactivating the last active admin of an organization.
+	ErrLastAdmin = errors.New("last active admin")
+)
+
 // Staff represents a row in the staff table.
 type Staff struct {
-	ID             string                 `json:"id"`
-	OrganizationID string                 `json:"organization_id"`
-	UserID         string                 `json:"user_id"`
-	Role           string                 `json:"role"`        // 'admin' or 'staff'
-	Permissions    map[string]interface{} `json:"permissions"` // JSONB
-	CreatedAt      time.Time              `json:"created_at"`
-	UpdatedAt      time.Time              `json:"updated_at"`
+	ID             string      `json:"id"`
+	OrganizationID string      `json:"organization_id"`
+	UserID         string      `json:"user_id"`
+	Role           string      `json:"role"`        // 'admin' or 'staff'
+	Permissions    Permissions `json:"permissions"` // JSONB
+	IsActive       bool        `json:"is_active"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// Membership is a user's active staff membership together with its organization.
+type Membership struct {
+	StaffID          string `json:"staff_id"`
+	OrganizationID   string `json:"organization_id"`
+	OrganizationName string `json:"organization_name"`
+	OrganizationSlug string `json:"organization_slug"`
+	Role             string `json:"role"`
+
+	// Set while the organization is suspended, see OrganizationRepository.SuspendOrg
+	Suspended bool `json:"suspended,omitempty"`
+	ReadOnly  bool `json:"read_only,omitempty"`
+}
+
+// InactiveStaff is an active staff member whose account has not been used since a cutoff.
+type InactiveStaff struct {
+	StaffID        string     `json:"staff_id"`
+	UserID         string     `json:"user_id"`
+	Email          string     `json:"email"`
+	FirstName      string     `json:"first_name"`
+	LastName       string     `json:"last_name"`
+	Role           string     `json:"role"`
+	LastLoginAt    *time.Time `json:"last_login_at"`    // Nil if they never logged in
+	LastActivityAt *time.Time `json:"last_activity_at"` // Nil if they were never active
+	JoinedAt       time.Time  `json:"joined_at"`
 }
 
 // StaffRepository handles database operations for staff.
@@ -29,8 +68,20 @@ func NewStaffRepository(db *database.Postgres) *StaffRepository {
 	return &StaffRepository{db: db}
 }
 
-// CreateStaff inserts a new staff member.
+// CreateStaff inserts a new staff member, or fails with a *QuotaError when the organization has no seat left.
 func (r *StaffRepository) CreateStaff(ctx context.Context, s *Staff) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := reserveQuota(ctx, tx, s.OrganizationID, QuotaStaff); err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO staff (
 			organization_id, user_id, role, permissions
@@ -38,12 +89,7 @@ func (r *StaffRepository) CreateStaff(ctx context.Context, s *Staff) error {
 			$1, $2, $3, $4
 		) RETURNING id, created_at, updated_at`
 
-	// Default permissions if nil
-	if s.Permissions == nil {
-		s.Permissions = make(map[string]interface{})
-	}
-
-	err := r.db.Pool.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		s.OrganizationID, s.UserID, s.Role, s.Permissions,
 	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
 
@@ -51,5 +97,190 @@ func (r *StaffRepository) CreateStaff(ctx context.Context, s *Staff) error {
 		return fmt.Errorf("failed to create staff: %w", err)
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit staff: %w", err)
+	}
+
 	return nil
 }
+
+// ListMembershipsByUser returns the active, non-deleted memberships of a user, oldest first.
+func (r *StaffRepository) ListMembershipsByUser(ctx context.Context, userID string) ([]Membership, error) {
+	query := `
+		SELECT
+			s.id, o.id, o.name, COALESCE(o.slug, ''), s.role, NOT o.is_active, NOT o.is_active AND o.suspension_read_only
+		FROM staff s
+		JOIN organizations o ON o.id = s.organization_id
+		WHERE s.user_id = $1 AND s.is_active = true AND s.deleted_at IS NULL AND o.deleted_at IS NULL
+		ORDER BY s.created_at, s.id`
+
+	rows, err := r.db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memberships: %w", err)
+	}
+	defer rows.Close()
+
+	memberships := []Membership{}
+	for rows.Next() {
+		var m Membership
+		if err := rows.Scan(&m.StaffID, &m.OrganizationID, &m.OrganizationName, &m.OrganizationSlug, &m.Role, &m.Suspended, &m.ReadOnly); err != nil {
+			return nil, fmt.Errorf("failed to scan membership: %w", err)
+		}
+		memberships = append(memberships, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list memberships: %w", err)
+	}
+
+	return memberships, nil
+}
+
+// GetStaffByUserAndOrg retrieves the active membership of a user in an organization.
+// Deactivated or soft-deleted memberships are reported as ErrStaffNotFound.
+func (r *StaffRepository) GetStaffByUserAndOrg(ctx context.Context, userID, orgID string) (*Staff, error) {
+	query := `
+		SELECT
+			id, organization_id, user_id, role, permissions, is_active, created_at, updated_at
+		FROM staff
+		WHERE user_id = $1 AND organization_id = $2 AND is_active = true AND deleted_at IS NULL`
+
+	var s Staff
+	err := r.db.Pool.QueryRow(ctx, query, userID, orgID).Scan(
+		&s.ID, &s.OrganizationID, &s.UserID, &s.Role, &s.Permissions, &s.IsActive, &s.CreatedAt, &s.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStaffNotFound
+		}
+		return nil, fmt.Errorf("failed to get staff: %w", err)
+	}
+
+	return &s, nil
+}
+
+// GetStaffByID retrieves a staff member of an organization by ID.
+func (r *StaffRepository) GetStaffByID(ctx context.Context, orgID, id string) (*Staff, error) {
+	query := `
+		SELECT
+			id, organization_id, user_id, role, permissions, is_active, created_at, updated_at
+		FROM staff
+		WHERE id = $1 AND organization_id = $2 AND deleted_at IS NULL`
+
+	var s Staff
+	err := r.db.Pool.QueryRow(ctx, query, id, orgID).Scan(
+		&s.ID, &s.OrganizationID, &s.UserID, &s.Role, &s.Permissions, &s.IsActive, &s.CreatedAt, &s.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStaffNotFound
+		}
+		return nil, fmt.Errorf("failed to get staff: %w", err)
+	}
+
+	return &s, nil
+}
+
+// DeactivateStaff marks a staff member of an organization as inactive.
+// It fails with ErrStaffOwner for the organization's owner and ErrLastAdmin for its last active admin;
+// the organization row is locked so concurrent deactivations can't remove every admin.
+func (r *StaffRepository) DeactivateStaff(ctx context.Context, orgID, id, reason string) (*Staff, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var ownerID string
+	err = tx.QueryRow(ctx, `SELECT owner_user_id FROM organizations WHERE id = $1 FOR UPDATE`, orgID).Scan(&ownerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStaffNotFound
+		}
+		return nil, fmt.Errorf("failed to lock organization: %w", err)
+	}
+
+	query := `
+		UPDATE staff
+		SET is_active = false, deactivated_at = now(), deactivation_reason = NULLIF($3, '')
+		WHERE id = $1 AND organization_id = $2 AND deleted_at IS NULL
+		RETURNING id, organization_id, user_id, role, permissions, is_active, created_at, updated_at`
+
+	var s Staff
+	err = tx.QueryRow(ctx, query, id, orgID, reason).Scan(
+		&s.ID, &s.OrganizationID, &s.UserID, &s.Role, &s.Permissions, &s.IsActive, &s.CreatedAt, &s.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStaffNotFound
+		}
+		return nil, fmt.Errorf("failed to deactivate staff: %w", err)
+	}
+
+	if s.UserID == ownerID {
+		return nil, ErrStaffOwner
+	}
+
+	if s.Role == "admin" {
+		var admins int
+		err = tx.QueryRow(ctx, `
+			SELECT count(*) FROM staff
+			WHERE organization_id = $1 AND role = 'admin' AND is_active = true AND deleted_at IS NULL`,
+			orgID,
+		).Scan(&admins)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count admins: %w", err)
+		}
+		if admins == 0 {
+			return nil, ErrLastAdmin
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit deactivation: %w", err)
+	}
+
+	return &s, nil
+}
+
+// ListInactiveStaff returns the active staff of an organization who have not been active since before,
+// least recently active first. Staff who were never active count from when they joined.
+func (r *StaffRepository) ListInactiveStaff(ctx context.Context, orgID string, before time.Time) ([]InactiveStaff, error) {
+	query := `
+		SELECT
+			s.id, u.id, u.email, COALESCE(u.first_name, ''), COALESCE(u.last_name, ''), s.role,
+			u.last_login_at, u.last_activity_at, s.created_at
+		FROM staff s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.organization_id = $1 AND s.is_active = true AND s.deleted_at IS NULL
+			AND u.is_active = true AND u.deleted_at IS NULL
+			AND COALESCE(u.last_activity_at, s.created_at) < $2
+		ORDER BY COALESCE(u.last_activity_at, s.created_at), s.id`
+
+	rows, err := r.db.Pool.Query(ctx, query, orgID, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inactive staff: %w", err)
+	}
+	defer rows.Close()
+
+	inactive := []InactiveStaff{}
+	for rows.Next() {
+		var s InactiveStaff
+		if err := rows.Scan(
+			&s.StaffID, &s.UserID, &s.Email, &s.FirstName, &s.LastName, &s.Role,
+			&s.LastLoginAt, &s.LastActivityAt, &s.JoinedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan inactive staff: %w", err)
+		}
+		inactive = append(inactive, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list inactive staff: %w", err)
+	}
+
+	return inactive, nil
+}
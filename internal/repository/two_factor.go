@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTwoFactorAlreadyEnabled is returned when enrolling a user who already has two-factor authentication.
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	// ErrTOTPStepUsed is returned when a TOTP code for an already used time step is presented again.
+	ErrTOTPStepUsed = errors.New("totp code already used")
+	// ErrRecoveryCodeNotFound is returned when a recovery code is unknown or already used.
+	ErrRecoveryCodeNotFound = errors.New("recovery code not found")
+)
+
+// SetPendingTwoFactorSecret stores a TOTP secret awaiting confirmation.
+// Enrolling again before confirming replaces the pending secret.
+func (r *UserRepository) SetPendingTwoFactorSecret(ctx context.Context, userID, secret string) error {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE users SET two_factor_secret = $2, two_factor_last_step = NULL
+		WHERE id = $1 AND two_factor_enabled = false`,
+		userID, secret,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store two-factor secret: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTwoFactorAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableTwoFactor turns on two-factor authentication after the first code was confirmed
+// and replaces the user's recovery codes with the given hashes.
+// step is the TOTP step of the confirming code, recorded so that code cannot be replayed.
+func (r *UserRepository) EnableTwoFactor(ctx context.Context, userID string, step int64, codeHashes []string) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE users SET two_factor_enabled = true, two_factor_last_step = $2
+		WHERE id = $1 AND two_factor_enabled = false AND two_factor_secret IS NOT NULL`,
+		userID, step,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTwoFactorAlreadyEnabled
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear recovery codes: %w", err)
+	}
+	for _, hash := range codeHashes {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`,
+			userID, hash,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store recovery code: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit two-factor enrolment: %w", err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep records that the code for step was accepted.
+// It returns ErrTOTPStepUsed if a code for this or a later step was already accepted.
+func (r *UserRepository) UseTOTPStep(ctx context.Context, userID string, step int64) error {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE users SET two_factor_last_step = $2
+		WHERE id = $1 AND (two_factor_last_step IS NULL OR two_factor_last_step < $2)`,
+		userID, step,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record totp step: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTOTPStepUsed
+	}
+	return nil
+}
+
+// UseRecoveryCode consumes an unused recovery code of a user.
+func (r *UserRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) error {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE user_recovery_codes SET used_at = now()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID, codeHash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRecoveryCodeNotFound
+	}
+	return nil
+}
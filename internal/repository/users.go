@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 
 	"github.com/off-by-2/sal/internal/database"
 )
@@ -17,6 +18,8 @@ var (
 	ErrUserNotFound = errors.New("user not found")
 	// ErrDuplicateEmail is returned when an email is already taken.
 	ErrDuplicateEmail = errors.New("email already exists")
+	// ErrProviderLinked is returned when an account is already linked to another sign-in provider.
+	ErrProviderLinked = errors.New("account is linked to another provider")
 )
 
 // User represents a row in the users table.
@@ -33,6 +36,47 @@ type User struct {
 	IsActive        bool      `json:"is_active"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
+
+	FailedLoginAttempts int        `json:"-"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+
+	TwoFactorEnabled bool    `json:"two_factor_enabled"`
+	TwoFactorSecret  *string `json:"-"` // Pending until TwoFactorEnabled is set
+
+	LastLoginAt    *time.Time `json:"last_login_at,omitempty"`
+	LastActivityAt *time.Time `json:"last_activity_at,omitempty"` // Written in batches, up to auth.DefaultActivityInterval late
+
+	IsPlatformAdmin bool `json:"-"` // Support staff of the platform, who may impersonate users
+}
+
+// IsLocked reports whether the account is currently locked out.
+func (u *User) IsLocked() bool {
+	return u.LockedUntil != nil && time.Now().Before(*u.LockedUntil)
+}
+
+// userColumns is the column list read by scanUser.
+// Accounts created through a sign-in provider have no password hash and may have no name.
+const userColumns = `
+	id, email, email_verified, COALESCE(password_hash, ''), auth_provider, COALESCE(first_name, ''), COALESCE(last_name, ''), phone, profile_image_url, is_active, created_at, updated_at,
+	failed_login_attempts, locked_until, two_factor_enabled, two_factor_secret, last_login_at, last_activity_at,
+	is_platform_admin`
+
+// scanUser scans a row selected with userColumns.
+func scanUser(row pgx.Row) (*User, error) {
+	var u User
+	err := row.Scan(
+		&u.ID, &u.Email, &u.EmailVerified, &u.PasswordHash, &u.AuthProvider, &u.FirstName, &u.LastName,
+		&u.Phone, &u.ProfileImageURL, &u.IsActive, &u.CreatedAt, &u.UpdatedAt,
+		&u.FailedLoginAttempts, &u.LockedUntil, &u.TwoFactorEnabled, &u.TwoFactorSecret,
+		&u.LastLoginAt, &u.LastActivityAt, &u.IsPlatformAdmin,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &u, nil
 }
 
 // UserRepository handles database operations for users.
@@ -72,24 +116,197 @@ func (r *UserRepository) CreateUser(ctx context.Context, u *User) error {
 
 // GetUserByEmail retrieves a user by their email address.
 func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
-	query := `
-		SELECT 
-			id, email, email_verified, password_hash, auth_provider, first_name, last_name, phone, profile_image_url, is_active, created_at, updated_at
-		FROM users
-		WHERE email = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
+	return scanUser(r.db.Pool.QueryRow(ctx, query, email))
+}
 
-	var u User
-	err := r.db.Pool.QueryRow(ctx, query, email).Scan(
-		&u.ID, &u.Email, &u.EmailVerified, &u.PasswordHash, &u.AuthProvider, &u.FirstName, &u.LastName,
-		&u.Phone, &u.ProfileImageURL, &u.IsActive, &u.CreatedAt, &u.UpdatedAt,
+// GetUserByID retrieves a user by their ID.
+func (r *UserRepository) GetUserByID(ctx context.Context, id string) (*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	return scanUser(r.db.Pool.QueryRow(ctx, query, id))
+}
+
+// GetUserByProvider retrieves a user by the subject identifier of their sign-in provider.
+func (r *UserRepository) GetUserByProvider(ctx context.Context, provider, subject string) (*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users
+		WHERE auth_provider = $1 AND auth_provider_id = $2 AND deleted_at IS NULL`
+	return scanUser(r.db.Pool.QueryRow(ctx, query, provider, subject))
+}
+
+// CreateProviderUser inserts a user that signs in through provider, without a password.
+// The provider vouches for the email, so it is stored as verified.
+func (r *UserRepository) CreateProviderUser(ctx context.Context, u *User, subject string) error {
+	err := r.db.Pool.QueryRow(ctx, `
+		INSERT INTO users (
+			email, email_verified, first_name, last_name, is_active, auth_provider, auth_provider_id
+		) VALUES (
+			$1, true, NULLIF($2, ''), NULLIF($3, ''), true, $4, $5
+		) RETURNING id, created_at, updated_at`,
+		u.Email, u.FirstName, u.LastName, u.AuthProvider, subject,
+	).Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateEmail
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	u.EmailVerified = true
+	u.IsActive = true
+	return nil
+}
+
+// LinkProvider links an email/password account to a sign-in provider and marks its email verified.
+// Accounts can only be linked to one provider; it returns ErrProviderLinked if the account already is.
+func (r *UserRepository) LinkProvider(ctx context.Context, id, provider, subject string) error {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE users SET auth_provider = $2, auth_provider_id = $3, email_verified = true
+		WHERE id = $1 AND auth_provider = 'email'`,
+		id, provider, subject,
 	)
+	if err != nil {
+		return fmt.Errorf("failed to link provider: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrProviderLinked
+	}
+	return nil
+}
+
+// RecordFailedLogin increments the failed login counter of a user.
+// lockFor maps the new counter to a lock duration; a positive duration locks the account.
+// It returns when the account is locked until, or nil if it is not locked.
+func (r *UserRepository) RecordFailedLogin(ctx context.Context, id string, lockFor func(failures int) time.Duration) (*time.Time, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
+	var failures int
+	err = tx.QueryRow(ctx, `
+		UPDATE users SET failed_login_attempts = failed_login_attempts + 1
+		WHERE id = $1
+		RETURNING failed_login_attempts`,
+		id,
+	).Scan(&failures)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("failed to record failed login: %w", err)
 	}
 
-	return &u, nil
+	var lockedUntil *time.Time
+	if d := lockFor(failures); d > 0 {
+		until := time.Now().Add(d)
+		lockedUntil = &until
+		if _, err := tx.Exec(ctx, `UPDATE users SET locked_until = $2 WHERE id = $1`, id, until); err != nil {
+			return nil, fmt.Errorf("failed to lock user: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit failed login: %w", err)
+	}
+
+	return lockedUntil, nil
+}
+
+// RecordSuccessfulLogin stamps the login time and clears the failed login counter and any lock.
+func (r *UserRepository) RecordSuccessfulLogin(ctx context.Context, id string) error {
+	_, err := r.db.Pool.Exec(ctx, `
+		UPDATE users SET
+			failed_login_attempts = 0,
+			locked_until = NULL,
+			last_login_at = now(),
+			last_activity_at = now()
+		WHERE id = $1`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record login: %w", err)
+	}
+	return nil
+}
+
+// TouchUsers records when users were last active, in one statement.
+// Times never move backwards, so late or repeated batches are harmless.
+func (r *UserRepository) TouchUsers(ctx context.Context, seen map[string]time.Time) error {
+	ids := make([]string, 0, len(seen))
+	times := make([]time.Time, 0, len(seen))
+	for id, at := range seen {
+		ids = append(ids, id)
+		times = append(times, at)
+	}
+
+	_, err := r.db.Pool.Exec(ctx, `
+		UPDATE users u SET last_activity_at = v.seen_at
+		FROM unnest($1::text[], $2::timestamptz[]) AS v(id, seen_at)
+		WHERE u.id = v.id::uuid AND (u.last_activity_at IS NULL OR u.last_activity_at < v.seen_at)`,
+		ids, times,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+	return nil
+}
+
+// UnlockUser clears the lockout state of a user.
+func (r *UserRepository) UnlockUser(ctx context.Context, id string) error {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE users SET failed_login_attempts = 0, locked_until = NULL WHERE id = $1`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to unlock user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// MarkEmailVerified flags the email of a user as verified.
+// The email must still match, so a link sent to a previous address cannot verify a new one.
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id, email string) error {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE users SET email_verified = true WHERE id = $1 AND email = $2`,
+		id, email,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// RehashPassword replaces a password hash with an upgraded hash of the same password.
+// It does nothing if the hash changed in the meantime, so it cannot undo a concurrent password change.
+func (r *UserRepository) RehashPassword(ctx context.Context, id, oldHash, newHash string) error {
+	_, err := r.db.Pool.Exec(ctx, `
+		UPDATE users SET password_hash = $3 WHERE id = $1 AND password_hash = $2`,
+		id, oldHash, newHash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to rehash password: %w", err)
+	}
+	return nil
+}
+
+// UpdatePassword sets the password hash of a user.
+func (r *UserRepository) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	tag, err := r.db.Pool.Exec(ctx, `UPDATE users SET password_hash = $2 WHERE id = $1`, id, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -42,6 +43,12 @@ func Error(w http.ResponseWriter, status int, message string) {
 	JSON(w, status, map[string]string{"message": message})
 }
 
+// ErrorCode sends an error response with a machine-readable code,
+// for errors clients have to tell apart and act on.
+func ErrorCode(w http.ResponseWriter, status int, code, message string) {
+	JSON(w, status, map[string]string{"code": code, "message": message})
+}
+
 // ValidationError sends a response with detailed validation errors.
 // It parses go-playground/validator errors into a simplified map.
 func ValidationError(w http.ResponseWriter, err error) {
@@ -57,25 +64,46 @@ func ValidationError(w http.ResponseWriter, err error) {
 	Error(w, http.StatusBadRequest, "Invalid request payload")
 }
 
+// FieldError sends a validation error for a single field, for rules checked outside the validator.
+// The tag selects the same message ValidationError would use.
+func FieldError(w http.ResponseWriter, field, tag string) {
+	msg, ok := messages[tag]
+	if !ok {
+		msg = "Invalid value"
+	}
+	JSON(w, http.StatusUnprocessableEntity, map[string]string{field: msg})
+}
+
+// messages maps validator tags to user-friendly messages.
+var messages = map[string]string{
+	"required":          "This field is required",
+	"required_without":  "This field is required",
+	"email":             "Invalid email format",
+	"min":               "Value is too short",
+	"max":               "Value is too long",
+	"range":             "Value is out of range",
+	"uppercase":         "Must contain at least one uppercase letter",
+	"lowercase":         "Must contain at least one lowercase letter",
+	"number":            "Must contain at least one number",
+	"special":           "Must contain at least one special character",
+	"password_length":   "Password length is out of range",
+	"common_password":   "This password is too common",
+	"personal_password": "Must not contain your email or organization name",
+	"timezone":          "Unknown time zone (use an IANA name like Europe/Paris)",
+	"datetime":          "Invalid date (use YYYY-MM-DD)",
+}
+
 // msgForTag converts validator tags to user-friendly messages.
+// Aliases (like "password") report the rule that actually failed.
 func msgForTag(fe validator.FieldError) string {
-	switch fe.Tag() {
-	case "required":
-		return "This field is required"
-	case "email":
-		return "Invalid email format"
-	case "min":
-		return "Value is too short"
-	case "max":
-		return "Value is too long"
-	case "uppercase":
-		return "Must contain at least one uppercase letter"
-	case "lowercase":
-		return "Must contain at least one lowercase letter"
-	case "number":
-		return "Must contain at least one number"
-	case "special":
-		return "Must contain at least one special character"
+	switch fe.ActualTag() {
+	case "excluded_with":
+		return "Cannot be combined with " + fe.Param()
+	case "oneof":
+		return "Must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	}
+	if msg, ok := messages[fe.ActualTag()]; ok {
+		return msg
 	}
 	return fe.Error() // Default fallback
 }
@@ -103,3 +103,39 @@ func TestValidationError(t *testing.T) {
 	// and triggering it.
 	// But we can test the fallback for generic errors, which we did.
 }
+
+func TestFieldError(t *testing.T) {
+	w := httptest.NewRecorder()
+	FieldError(w, "Password", "personal_password")
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", w.Code)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	d, ok := resp.Data.(map[string]interface{})
+	if !ok || d["Password"] != messages["personal_password"] {
+		t.Errorf("Unexpected data %v", resp.Data)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	w := httptest.NewRecorder()
+	ErrorCode(w, http.StatusUnauthorized, "reauthentication_required", "Please confirm your identity")
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	d, ok := resp.Data.(map[string]interface{})
+	if !ok || d["code"] != "reauthentication_required" || d["message"] != "Please confirm your identity" {
+		t.Errorf("Unexpected data %v", resp.Data)
+	}
+}
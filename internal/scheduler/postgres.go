@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/off-by-2/sal/internal/database"
+)
+
+// LeaderLock names the advisory lock held by the replica running the jobs.
+const LeaderLock = "sal/scheduler"
+
+// PostgresElector elects the leader with a session-level Postgres advisory lock:
+// the replica holding it keeps a dedicated connection open, and the lock is released
+// when it resigns or its connection is lost (e.g. the process died), letting another replica take it.
+type PostgresElector struct {
+	db   *database.Postgres
+	lock string
+
+	conn *pgx.Conn // Holds the lock while leading; taken out of the pool
+}
+
+// NewPostgresElector creates an elector competing for the advisory lock named lock.
+func NewPostgresElector(db *database.Postgres, lock string) *PostgresElector {
+	return &PostgresElector{db: db, lock: lock}
+}
+
+// Lead implements Elector.
+func (e *PostgresElector) Lead(ctx context.Context) (bool, error) {
+	// 1. Still leading as long as the session holding the lock is alive
+	if e.conn != nil {
+		err := e.conn.Ping(ctx)
+		if err == nil {
+			return true, nil
+		}
+		_ = e.conn.Close(ctx)
+		e.conn = nil
+		return false, fmt.Errorf("lost leader connection: %w", err)
+	}
+
+	// 2. Try to take the lock
+	conn, err := e.db.Pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	var locked bool
+	err = conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, e.lock).Scan(&locked)
+	if err != nil {
+		// Don't hand the pool a session that may hold the lock
+		_ = conn.Conn().Close(ctx)
+		conn.Release()
+		return false, fmt.Errorf("failed to take leader lock: %w", err)
+	}
+	if !locked {
+		conn.Release()
+		return false, nil
+	}
+
+	// 3. Keep the session for as long as we lead
+	e.conn = conn.Hijack()
+	return true, nil
+}
+
+// Resign implements Elector. Closing the session releases the lock.
+func (e *PostgresElector) Resign(ctx context.Context) error {
+	if e.conn == nil {
+		return nil
+	}
+	err := e.conn.Close(ctx)
+	e.conn = nil
+	if err != nil {
+		return fmt.Errorf("failed to release leader lock: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Package scheduler runs periodic background jobs (e.g. expiring invitations) inside the API process.
+// Every replica runs a Scheduler, but only the elected leader runs jobs, so they never run twice.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultElectionInterval is how often a Scheduler checks its leadership and runs the jobs that are due,
+// and so how long a replica takes to take over from a leader that went away.
+const DefaultElectionInterval = 30 * time.Second
+
+// Elector decides which replica runs the jobs.
+type Elector interface {
+	// Lead reports whether this replica is the leader, trying to become it if it is not.
+	Lead(ctx context.Context) (bool, error)
+	// Resign gives up leadership, if held, so that another replica takes over.
+	Resign(ctx context.Context) error
+}
+
+// Job is a function run every Interval.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+
+	next time.Time // When the job is due again; zero runs it on the first tick as leader
+}
+
+// Scheduler runs jobs while its Elector says this replica is the leader.
+// Jobs run one after another, so a slow job delays the others rather than overlapping itself.
+type Scheduler struct {
+	elector  Elector
+	interval time.Duration
+
+	mu     sync.Mutex
+	jobs   []*Job
+	leader bool
+
+	ctx    context.Context // Cancelled by Stop, interrupting a running job
+	cancel context.CancelFunc
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// New creates a Scheduler checking its leadership with elector every interval.
+// Register jobs with Every, then call Start to begin running them and Stop to end.
+func New(elector Elector, interval time.Duration) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		elector:  elector,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Every registers run as the job name, run every interval (at the scheduler's interval at most).
+func (s *Scheduler) Every(name string, interval time.Duration, run func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &Job{Name: name, Interval: interval, Run: run})
+}
+
+// Start checks leadership and runs due jobs immediately, then every interval until Stop is called.
+func (s *Scheduler) Start() {
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.Tick(s.ctx, time.Now())
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop interrupts the running job, if any, waits for it to return and resigns leadership.
+// It must only be called once, after Start.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	close(s.stop)
+	s.cancel()
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return s.elector.Resign(ctx)
+}
+
+// Tick runs the jobs due at now if this replica is the leader.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) {
+	leader, err := s.elector.Lead(ctx)
+	if err != nil {
+		log.Printf("Failed to elect scheduler leader: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if leader != s.leader {
+		s.leader = leader
+		if leader {
+			log.Println("Scheduler: this replica now runs background jobs")
+		} else {
+			log.Println("Scheduler: this replica no longer runs background jobs")
+		}
+	}
+	if !leader {
+		return
+	}
+
+	for _, job := range s.jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		if now.Before(job.next) {
+			continue
+		}
+		job.next = now.Add(job.Interval)
+		if err := run(ctx, job); err != nil {
+			log.Printf("Job %s failed: %v", job.Name, err)
+		}
+	}
+}
+
+// run runs job, turning a panic into an error so that one bad job does not take the API down.
+func run(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx)
+}
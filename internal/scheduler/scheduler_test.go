@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeElector makes the replica lead while leader is set.
+type fakeElector struct {
+	leader   bool
+	err      error
+	resigned bool
+}
+
+func (f *fakeElector) Lead(context.Context) (bool, error) {
+	return f.leader, f.err
+}
+
+func (f *fakeElector) Resign(context.Context) error {
+	f.resigned = true
+	f.leader = false
+	return nil
+}
+
+func TestScheduler_RunsDueJobsAsLeader(t *testing.T) {
+	elector := &fakeElector{}
+	s := New(elector, time.Minute)
+
+	var hourly, daily int
+	s.Every("hourly", time.Hour, func(context.Context) error { hourly++; return nil })
+	s.Every("daily", 24*time.Hour, func(context.Context) error { daily++; return nil })
+
+	ctx := context.Background()
+	now := time.Now()
+
+	// Followers run nothing
+	s.Tick(ctx, now)
+	if hourly != 0 || daily != 0 {
+		t.Fatalf("Expected no runs as follower, got hourly=%d daily=%d", hourly, daily)
+	}
+
+	// The new leader runs everything, then each job on its own interval
+	elector.leader = true
+	s.Tick(ctx, now)
+	s.Tick(ctx, now.Add(30*time.Minute))
+	s.Tick(ctx, now.Add(time.Hour))
+	if hourly != 2 || daily != 1 {
+		t.Errorf("Expected hourly=2 daily=1, got hourly=%d daily=%d", hourly, daily)
+	}
+
+	// An election error counts as not leading
+	elector.err = errors.New("database down")
+	elector.leader = false
+	s.Tick(ctx, now.Add(2*time.Hour))
+	if hourly != 2 {
+		t.Errorf("Expected no run without leadership, got hourly=%d", hourly)
+	}
+}
+
+func TestScheduler_FailingJobs(t *testing.T) {
+	s := New(&fakeElector{leader: true}, time.Minute)
+
+	var ran bool
+	s.Every("failing", time.Hour, func(context.Context) error { return errors.New("boom") })
+	s.Every("panicking", time.Hour, func(context.Context) error { panic("boom") })
+	s.Every("fine", time.Hour, func(context.Context) error { ran = true; return nil })
+
+	s.Tick(context.Background(), time.Now())
+	if !ran {
+		t.Error("Expected a failing or panicking job not to stop the others")
+	}
+}
+
+func TestScheduler_StopInterruptsAndResigns(t *testing.T) {
+	elector := &fakeElector{leader: true}
+	s := New(elector, time.Hour)
+
+	started := make(chan struct{})
+	s.Every("slow", time.Hour, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	s.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if !elector.resigned {
+		t.Error("Expected the scheduler to resign leadership")
+	}
+}
@@ -0,0 +1,33 @@
+-- +goose Up
+
+ALTER TABLE public.users
+    ADD COLUMN two_factor_last_step bigint;
+
+COMMENT ON COLUMN public.users.two_factor_last_step IS 'TOTP time step of the last accepted code. Codes at or before this step are rejected to prevent replay.';
+
+--
+-- Name: user_recovery_codes; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.user_recovery_codes (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    user_id uuid NOT NULL,
+    code_hash character(64) NOT NULL,
+    used_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+COMMENT ON TABLE public.user_recovery_codes IS 'SHA-256 hashes of single-use two-factor recovery codes. Replaced as a set on every enrolment.';
+
+ALTER TABLE ONLY public.user_recovery_codes
+    ADD CONSTRAINT user_recovery_codes_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.user_recovery_codes
+    ADD CONSTRAINT user_recovery_codes_user_hash_key UNIQUE (user_id, code_hash);
+
+ALTER TABLE ONLY public.user_recovery_codes
+    ADD CONSTRAINT fk_recovery_code_user FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+-- +goose Down
+DROP TABLE IF EXISTS public.user_recovery_codes;
+ALTER TABLE public.users DROP COLUMN IF EXISTS two_factor_last_step;
@@ -0,0 +1,11 @@
+-- +goose Up
+
+--
+-- Name: idx_user_auth_identity; Type: INDEX; Schema: public; Owner: postgres
+--
+-- A sign-in provider subject identifies exactly one account.
+
+CREATE UNIQUE INDEX idx_user_auth_identity ON public.users USING btree (auth_provider, auth_provider_id) WHERE ((auth_provider_id IS NOT NULL) AND (deleted_at IS NULL));
+
+-- +goose Down
+DROP INDEX IF EXISTS public.idx_user_auth_identity;
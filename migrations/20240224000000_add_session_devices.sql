@@ -0,0 +1,21 @@
+-- +goose Up
+
+--
+-- Name: user_sessions; Type: TABLE; Schema: public; Owner: postgres
+--
+-- Client details shown in the session list, so users can recognize (and revoke) their devices.
+
+ALTER TABLE public.user_sessions
+    ADD COLUMN user_agent character varying(512),
+    ADD COLUMN ip_address inet,
+    ADD COLUMN last_used_at timestamp with time zone DEFAULT now() NOT NULL;
+
+COMMENT ON COLUMN public.user_sessions.ip_address IS 'Client IP of the last login or refresh.';
+
+COMMENT ON COLUMN public.user_sessions.last_used_at IS 'Time of the last login or refresh. Access tokens are not tracked, so this lags by up to their lifetime.';
+
+-- +goose Down
+ALTER TABLE public.user_sessions
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS ip_address,
+    DROP COLUMN IF EXISTS last_used_at;
@@ -0,0 +1,27 @@
+-- +goose Up
+
+--
+-- Name: users; Type: TABLE; Schema: public; Owner: postgres
+--
+-- Platform admins are the support team operating the platform, not staff of an organization.
+-- There is deliberately no endpoint granting it: set it in the database.
+
+ALTER TABLE public.users
+    ADD COLUMN is_platform_admin boolean DEFAULT false NOT NULL;
+
+--
+-- Name: user_sessions; Type: TABLE; Schema: public; Owner: postgres
+--
+
+ALTER TABLE public.user_sessions
+    ADD COLUMN impersonator_id uuid;
+
+COMMENT ON COLUMN public.user_sessions.impersonator_id IS 'Platform admin acting as the user. Impersonation sessions have no refresh tokens.';
+
+ALTER TABLE ONLY public.user_sessions
+    ADD CONSTRAINT fk_session_impersonator FOREIGN KEY (impersonator_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE public.user_sessions DROP CONSTRAINT IF EXISTS fk_session_impersonator;
+ALTER TABLE public.user_sessions DROP COLUMN IF EXISTS impersonator_id;
+ALTER TABLE public.users DROP COLUMN IF EXISTS is_platform_admin;
@@ -0,0 +1,20 @@
+-- +goose Up
+
+--
+-- Name: user_sessions; Type: TABLE; Schema: public; Owner: postgres
+--
+-- When and how the user last proved their credentials in the session, carried by its access tokens
+-- as "auth_time" and "amr" so sensitive operations can require a recent re-authentication.
+
+ALTER TABLE public.user_sessions
+    ADD COLUMN auth_time timestamp with time zone DEFAULT now() NOT NULL,
+    ADD COLUMN amr text[] DEFAULT '{}'::text[] NOT NULL;
+
+UPDATE public.user_sessions SET auth_time = created_at;
+
+COMMENT ON COLUMN public.user_sessions.amr IS 'Authentication methods (RFC 8176), e.g. {pwd,otp,mfa}.';
+
+-- +goose Down
+ALTER TABLE public.user_sessions
+    DROP COLUMN IF EXISTS auth_time,
+    DROP COLUMN IF EXISTS amr;
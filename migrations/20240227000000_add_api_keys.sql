@@ -0,0 +1,52 @@
+-- +goose Up
+
+--
+-- Name: api_keys; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.api_keys (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    organization_id uuid NOT NULL,
+    name character varying(100) NOT NULL,
+    prefix character varying(20) NOT NULL,
+    key_hash character(64) NOT NULL,
+    capabilities text[] DEFAULT '{}'::text[] NOT NULL,
+    created_by uuid,
+    expires_at timestamp with time zone,
+    last_used_at timestamp with time zone,
+    revoked_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+COMMENT ON TABLE public.api_keys IS 'Organization credentials for services (transcription workers, EHR bridge). Only the SHA-256 hash of a key is stored.';
+
+COMMENT ON COLUMN public.api_keys.prefix IS 'Public start of the key ("sal_" and 8 hex chars), shown in listings to identify it.';
+
+COMMENT ON COLUMN public.api_keys.capabilities IS 'Capabilities granted, as checked by RequirePermission (e.g. notes.create). Keys have no role.';
+
+ALTER TABLE ONLY public.api_keys
+    ADD CONSTRAINT api_keys_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.api_keys
+    ADD CONSTRAINT api_keys_prefix_key UNIQUE (prefix);
+
+CREATE INDEX idx_api_key_org ON public.api_keys USING btree (organization_id, created_at DESC);
+
+ALTER TABLE ONLY public.api_keys
+    ADD CONSTRAINT fk_api_key_org FOREIGN KEY (organization_id) REFERENCES public.organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.api_keys
+    ADD CONSTRAINT fk_api_key_creator FOREIGN KEY (created_by) REFERENCES public.users(id) ON DELETE SET NULL;
+
+--
+-- Name: activity_log; Type: TABLE; Schema: public; Owner: postgres
+--
+
+ALTER TABLE public.activity_log
+    ADD COLUMN api_key_id uuid;
+
+COMMENT ON COLUMN public.activity_log.api_key_id IS 'API key that acted, for requests authenticated with one (user_id is then NULL).';
+
+-- +goose Down
+ALTER TABLE public.activity_log DROP COLUMN IF EXISTS api_key_id;
+DROP TABLE IF EXISTS public.api_keys;
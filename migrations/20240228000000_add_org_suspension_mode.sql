@@ -0,0 +1,16 @@
+-- +goose Up
+
+--
+-- Name: organizations; Type: TABLE; Schema: public; Owner: postgres
+--
+-- A suspended organization (is_active = false) is locked out entirely, unless suspended in read-only mode:
+-- its staff can then still sign in and read (e.g. to export their data) but not write.
+
+ALTER TABLE public.organizations
+    ADD COLUMN suspension_read_only boolean DEFAULT false NOT NULL;
+
+COMMENT ON COLUMN public.organizations.suspension_read_only IS 'While suspended, whether staff keep read-only access.';
+
+-- +goose Down
+ALTER TABLE public.organizations
+    DROP COLUMN IF EXISTS suspension_read_only;